@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// readyThreshold is how stale SyncStats.LastSync can be before /readyz
+// reports the daemon not ready.
+const readyThreshold = 10 * time.Minute
+
+// AdminServer exposes HTTP endpoints operators can point container health
+// probes, Prometheus, and dashboards at, backed by the same
+// HealthCheck/SyncStats data the daemon already logs periodically via
+// statusReporter.
+type AdminServer struct {
+	addr   string
+	app    *Application
+	server *http.Server
+	log    *Logger
+}
+
+// NewAdminServer returns an AdminServer that will bind addr once Start is
+// called.
+func NewAdminServer(addr string, app *Application) *AdminServer {
+	return &AdminServer{addr: addr, app: app, log: logger.With("component", "admin")}
+}
+
+// Start binds addr and begins serving in the background. The returned
+// error is only for bind failures; serve-time errors are logged.
+func (a *AdminServer) Start() error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", a.handleHealthz)
+	mux.HandleFunc("/readyz", a.handleReadyz)
+	mux.HandleFunc("/stats", a.handleStats)
+	mux.HandleFunc("/metrics", a.handleMetrics)
+
+	ln, err := net.Listen("tcp", a.addr)
+	if err != nil {
+		return fmt.Errorf("failed to bind admin address %s: %w", a.addr, err)
+	}
+
+	a.server = &http.Server{Addr: a.addr, Handler: mux}
+	go func() {
+		if err := a.server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			a.log.Error("Admin server stopped unexpectedly", "error", err)
+		}
+	}()
+
+	a.log.Info("Admin server listening", "addr", a.addr)
+	return nil
+}
+
+// Stop gracefully shuts the admin server down.
+func (a *AdminServer) Stop(ctx context.Context) error {
+	if a.server == nil {
+		return nil
+	}
+	return a.server.Shutdown(ctx)
+}
+
+// handleHealthz is the liveness probe: can this process touch its own
+// filesystem at all.
+func (a *AdminServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	ok, msg := HealthCheck()
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	fmt.Fprintln(w, msg)
+}
+
+// handleReadyz is the readiness probe: it reports not ready when
+// SyncStats.StartTime is unset or the last completed sync is older than
+// readyThreshold. StartTime is set when the SyncManager is constructed,
+// not when it starts, and a daemon that has not completed a sync yet is
+// reported ready.
+func (a *AdminServer) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	stats := a.app.syncManager.GetStats()
+
+	if stats.StartTime.IsZero() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, "sync manager not started")
+		return
+	}
+	if !stats.LastSync.IsZero() && time.Since(stats.LastSync) > readyThreshold {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, "last sync %s ago exceeds readiness threshold\n", time.Since(stats.LastSync))
+		return
+	}
+
+	fmt.Fprintln(w, "ready")
+}
+
+// handleStats dumps the current SyncStats as JSON.
+func (a *AdminServer) handleStats(w http.ResponseWriter, r *http.Request) {
+	stats := a.app.syncManager.GetStats()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(stats)
+}
+
+// handleMetrics renders SyncStats in Prometheus text exposition format.
+func (a *AdminServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	stats := a.app.syncManager.GetStats()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	fmt.Fprintln(w, "# HELP tm_isync_files_uploaded_total Total files uploaded to the server.")
+	fmt.Fprintln(w, "# TYPE tm_isync_files_uploaded_total counter")
+	fmt.Fprintf(w, "tm_isync_files_uploaded_total %d\n", stats.FilesUploaded)
+
+	fmt.Fprintln(w, "# HELP tm_isync_files_downloaded_total Total files synced from TM outputs to iCloud.")
+	fmt.Fprintln(w, "# TYPE tm_isync_files_downloaded_total counter")
+	fmt.Fprintf(w, "tm_isync_files_downloaded_total %d\n", stats.FilesDownloaded)
+
+	fmt.Fprintln(w, "# HELP tm_isync_errors_total Total sync errors encountered.")
+	fmt.Fprintln(w, "# TYPE tm_isync_errors_total counter")
+	fmt.Fprintf(w, "tm_isync_errors_total %d\n", stats.Errors)
+
+	fmt.Fprintln(w, "# HELP tm_isync_last_sync_timestamp_seconds Unix timestamp of the last completed sync.")
+	fmt.Fprintln(w, "# TYPE tm_isync_last_sync_timestamp_seconds gauge")
+	fmt.Fprintf(w, "tm_isync_last_sync_timestamp_seconds %d\n", stats.LastSync.Unix())
+
+	fmt.Fprintln(w, "# HELP tm_isync_uptime_seconds Seconds since the sync manager started.")
+	fmt.Fprintln(w, "# TYPE tm_isync_uptime_seconds gauge")
+	fmt.Fprintf(w, "tm_isync_uptime_seconds %.0f\n", time.Since(stats.StartTime).Seconds())
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AuditEventType enumerates the kinds of sync operations AuditLogger
+// records.
+type AuditEventType string
+
+const (
+	AuditUpload   AuditEventType = "upload"
+	AuditDownload AuditEventType = "download"
+	AuditDelete   AuditEventType = "delete"
+	AuditConflict AuditEventType = "conflict"
+	AuditError    AuditEventType = "error"
+)
+
+// AuditEvent is one JSON line written to the audit log.
+type AuditEvent struct {
+	Timestamp  time.Time      `json:"ts"`
+	Type       AuditEventType `json:"type"`
+	Path       string         `json:"path"`
+	Size       int64          `json:"size,omitempty"`
+	Checksum   string         `json:"checksum,omitempty"`
+	Direction  string         `json:"direction,omitempty"`
+	DurationMs int64          `json:"duration_ms,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// AuditLogger emits an append-only, one-JSON-line-per-event stream of
+// every upload/download/delete/conflict/error SyncManager performs, for
+// firms that need a compliance trail of what happened to a case file and
+// when. Lines are not signed or hash-chained, so an edit to the file is
+// not detected. When Config.AuditLog.Path is empty, Record is a no-op, so
+// callers never need to nil-check before calling it.
+type AuditLogger struct {
+	mu sync.Mutex
+	// out is what Record writes JSON lines to. It's the same file as
+	// file, except for a logger built without a path or by
+	// NewTestAuditLogger, or after rotation failed to reopen the file
+	// (then out is io.Discard and file is nil).
+	out io.Writer
+
+	path       string
+	maxSizeMB  int
+	maxAgeDays int
+	maxBackups int
+
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+// AuditLogConfig configures AuditLogger's destination and rotation
+// policy.
+type AuditLogConfig struct {
+	Path       string `json:"path"`
+	MaxSizeMB  int    `json:"max_size_mb"`
+	MaxAgeDays int    `json:"max_age_days"`
+	MaxBackups int    `json:"max_backups"`
+}
+
+// NewAuditLogger opens (creating if necessary) the audit log at cfg.Path.
+// If cfg.Path is empty, the returned logger discards every event.
+func NewAuditLogger(cfg AuditLogConfig) (*AuditLogger, error) {
+	if cfg.Path == "" {
+		return &AuditLogger{out: io.Discard}, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	f, err := os.OpenFile(cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to stat audit log: %w", err)
+	}
+
+	al := &AuditLogger{
+		path:       cfg.Path,
+		maxSizeMB:  cfg.MaxSizeMB,
+		maxAgeDays: cfg.MaxAgeDays,
+		maxBackups: cfg.MaxBackups,
+		file:       f,
+		out:        f,
+		size:       info.Size(),
+		openedAt:   time.Now(),
+	}
+	al.pruneOldBackups()
+	return al, nil
+}
+
+// NewTestAuditLogger wraps an arbitrary io.Writer (e.g. a bytes.Buffer)
+// with rotation disabled, for injecting into tests that want to assert on
+// emitted audit events without touching the filesystem.
+func NewTestAuditLogger(w io.Writer) *AuditLogger {
+	return &AuditLogger{out: w}
+}
+
+// Record appends event as one JSON line, filling in Timestamp if unset
+// and rotating the underlying file first if it's grown past MaxSizeMB or
+// aged past MaxAgeDays.
+func (a *AuditLogger) Record(event AuditEvent) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file != nil && a.shouldRotate() {
+		a.rotate()
+	}
+
+	n, err := a.out.Write(data)
+	if err == nil {
+		a.size += int64(n)
+	}
+}
+
+// shouldRotate reports whether the current audit log file has outgrown
+// MaxSizeMB or outlived MaxAgeDays. Callers must hold a.mu.
+func (a *AuditLogger) shouldRotate() bool {
+	if a.maxSizeMB > 0 && a.size >= int64(a.maxSizeMB)*1024*1024 {
+		return true
+	}
+	if a.maxAgeDays > 0 && time.Since(a.openedAt) >= time.Duration(a.maxAgeDays)*24*time.Hour {
+		return true
+	}
+	return false
+}
+
+// rotate closes the current audit log file, gzip-compresses it alongside
+// with a timestamp suffix, prunes old backups past MaxBackups, and opens
+// a fresh file at the original path. Callers must hold a.mu.
+func (a *AuditLogger) rotate() {
+	_ = a.file.Close()
+
+	rotatedPath := fmt.Sprintf("%s.%s", a.path, time.Now().UTC().Format("20060102T150405"))
+	if err := os.Rename(a.path, rotatedPath); err != nil {
+		logger.Warn("Failed to rename audit log for rotation", "path", a.path, "error", err)
+	} else if err := gzipAndRemove(rotatedPath); err != nil {
+		logger.Warn("Failed to gzip rotated audit log", "path", rotatedPath, "error", err)
+	}
+
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		logger.Error("Failed to reopen audit log after rotation, audit events will be dropped", "error", err)
+		a.file = nil
+		a.out = io.Discard
+		return
+	}
+
+	a.file = f
+	a.out = f
+	a.size = 0
+	a.openedAt = time.Now()
+	a.pruneOldBackups()
+}
+
+// gzipAndRemove compresses path to path+".gz" and removes the original.
+func gzipAndRemove(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(path + ".gz")
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+	if _, err := gz.Write(data); err != nil {
+		gz.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
+
+// pruneOldBackups keeps only the MaxBackups most recently rotated,
+// gzipped audit log files, removing older ones. Callers must hold a.mu.
+func (a *AuditLogger) pruneOldBackups() {
+	if a.maxBackups <= 0 {
+		return
+	}
+
+	matches, err := filepath.Glob(a.path + ".*.gz")
+	if err != nil || len(matches) <= a.maxBackups {
+		return
+	}
+
+	sort.Strings(matches) // the "YYYYMMDDTHHMMSS.gz" suffix sorts lexically == chronologically
+	for _, old := range matches[:len(matches)-a.maxBackups] {
+		_ = os.Remove(old)
+	}
+}
+
+// Close flushes and closes the underlying audit log file, if any.
+func (a *AuditLogger) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		return nil
+	}
+	return a.file.Close()
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// blobSidecarDir is the per-iCloud-root directory the content-addressed
+// blob archive (see BlobStore) and its bookkeeping live under.
+const blobSidecarDir = ".tm-sync/blobs"
+
+// blobSidecar is the small JSON file written alongside every archived blob,
+// recording enough to reconstruct the tree as of any given day's manifest.
+type blobSidecar struct {
+	RelPath     string    `json:"relative_path"`
+	SHA256      string    `json:"sha256"`
+	Version     int       `json:"version"`
+	OrigModTime time.Time `json:"orig_mod_time"`
+	Uploader    string    `json:"uploader"`
+	ArchivedAt  time.Time `json:"archived_at"`
+}
+
+// dayManifest lists every blob archived on one calendar day, so that day's
+// tree can be reconstructed without re-reading every sidecar individually.
+type dayManifest struct {
+	Date    string        `json:"date"`
+	Objects []blobSidecar `json:"objects"`
+}
+
+// BlobStore is the local half of chunk5-4's date-partitioned,
+// content-addressed archive: every uploaded file version is kept under
+// root/blobSidecarDir/YYYY/MM/DD/<sha256> (subject to PruneOrphaned),
+// alongside a JSON sidecar and a per-day manifest, so any day's manifest
+// can restore the tree as it stood that day and identical content shared
+// across matters is only ever stored once per day.
+type BlobStore struct {
+	root string
+
+	mu       sync.Mutex
+	versions map[string]int
+}
+
+func newBlobStore(root string) (*BlobStore, error) {
+	if err := os.MkdirAll(filepath.Join(root, blobSidecarDir), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create blob store directory: %w", err)
+	}
+	store := &BlobStore{root: root, versions: make(map[string]int)}
+	store.loadVersions()
+	return store, nil
+}
+
+func (b *BlobStore) versionsPath() string {
+	return filepath.Join(b.root, blobSidecarDir, "versions.json")
+}
+
+func (b *BlobStore) loadVersions() {
+	data, err := os.ReadFile(b.versionsPath())
+	if err != nil {
+		return
+	}
+	_ = json.Unmarshal(data, &b.versions)
+}
+
+func (b *BlobStore) saveVersions() error {
+	data, err := json.MarshalIndent(b.versions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(b.versionsPath(), data, 0644)
+}
+
+// nextVersion returns the next version number for relPath, persisting the
+// increment before returning it.
+func (b *BlobStore) nextVersion(relPath string) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.versions[relPath]++
+	version := b.versions[relPath]
+	if err := b.saveVersions(); err != nil {
+		return version, fmt.Errorf("failed to persist blob version: %w", err)
+	}
+	return version, nil
+}
+
+// datePath returns root/blobSidecarDir/YYYY/MM/DD for when.
+func (b *BlobStore) datePath(when time.Time) string {
+	return filepath.Join(b.root, blobSidecarDir, when.Format("2006"), when.Format("01"), when.Format("02"))
+}
+
+// Archive copies srcPath into the date-partitioned blob store under hash
+// (a no-op if that day already has the blob), writes its sidecar, and
+// appends an entry to that day's manifest.
+func (b *BlobStore) Archive(srcPath, relPath, hash string, version int, origModTime time.Time, uploader string) error {
+	when := time.Now()
+	dir := b.datePath(when)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create date partition: %w", err)
+	}
+
+	blobPath := filepath.Join(dir, hash)
+	if _, err := os.Stat(blobPath); os.IsNotExist(err) {
+		if err := copyFileContents(srcPath, blobPath); err != nil {
+			return fmt.Errorf("failed to archive blob: %w", err)
+		}
+	}
+
+	sidecar := blobSidecar{
+		RelPath:     relPath,
+		SHA256:      hash,
+		Version:     version,
+		OrigModTime: origModTime,
+		Uploader:    uploader,
+		ArchivedAt:  when,
+	}
+	sidecarData, err := json.MarshalIndent(sidecar, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal blob sidecar: %w", err)
+	}
+	if err := os.WriteFile(blobPath+".json", sidecarData, 0644); err != nil {
+		return fmt.Errorf("failed to write blob sidecar: %w", err)
+	}
+
+	return b.appendDayManifest(dir, when, sidecar)
+}
+
+func (b *BlobStore) appendDayManifest(dir string, when time.Time, sidecar blobSidecar) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	manifestPath := filepath.Join(dir, "manifest.json")
+	var manifest dayManifest
+	if data, err := os.ReadFile(manifestPath); err == nil {
+		_ = json.Unmarshal(data, &manifest)
+	}
+	manifest.Date = when.Format("2006-01-02")
+	manifest.Objects = append(manifest.Objects, sidecar)
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal day manifest: %w", err)
+	}
+	return os.WriteFile(manifestPath, data, 0644)
+}
+
+// PruneOrphaned removes archived blobs older than retentionDays that are
+// no longer the current (most recently archived) version for their
+// relative path, returning how many were removed. The current head per
+// path is kept regardless of age, so compliance/retention pruning never
+// deletes the one copy still in active use.
+func (b *BlobStore) PruneOrphaned(retentionDays int) (int, error) {
+	if retentionDays <= 0 {
+		return 0, nil
+	}
+
+	keep, err := b.currentHashes()
+	if err != nil {
+		return 0, fmt.Errorf("failed to determine current blob versions: %w", err)
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -retentionDays)
+	base := filepath.Join(b.root, blobSidecarDir)
+
+	years, err := os.ReadDir(base)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read blob store root: %w", err)
+	}
+
+	pruned := 0
+	for _, year := range years {
+		if !year.IsDir() {
+			continue
+		}
+		months, err := os.ReadDir(filepath.Join(base, year.Name()))
+		if err != nil {
+			continue
+		}
+		for _, month := range months {
+			if !month.IsDir() {
+				continue
+			}
+			days, err := os.ReadDir(filepath.Join(base, year.Name(), month.Name()))
+			if err != nil {
+				continue
+			}
+			for _, day := range days {
+				if !day.IsDir() {
+					continue
+				}
+				when, err := time.Parse("2006-01-02", fmt.Sprintf("%s-%s-%s", year.Name(), month.Name(), day.Name()))
+				if err != nil || !when.Before(cutoff) {
+					continue
+				}
+
+				dayPath := filepath.Join(base, year.Name(), month.Name(), day.Name())
+				n, err := b.pruneDay(dayPath, keep)
+				if err != nil {
+					continue
+				}
+				pruned += n
+			}
+		}
+	}
+	return pruned, nil
+}
+
+// currentHashes scans every day manifest once and returns the set of blob
+// hashes that are still the most recently archived version for their path.
+func (b *BlobStore) currentHashes() (map[string]bool, error) {
+	base := filepath.Join(b.root, blobSidecarDir)
+	latest := make(map[string]blobSidecar)
+
+	err := filepath.Walk(base, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || filepath.Base(path) != "manifest.json" {
+			return nil
+		}
+		data, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return nil
+		}
+		var manifest dayManifest
+		if jsonErr := json.Unmarshal(data, &manifest); jsonErr != nil {
+			return nil
+		}
+		for _, obj := range manifest.Objects {
+			if existing, ok := latest[obj.RelPath]; !ok || obj.ArchivedAt.After(existing.ArchivedAt) {
+				latest[obj.RelPath] = obj
+			}
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	hashes := make(map[string]bool, len(latest))
+	for _, obj := range latest {
+		hashes[obj.SHA256] = true
+	}
+	return hashes, nil
+}
+
+func (b *BlobStore) pruneDay(dayPath string, keep map[string]bool) (int, error) {
+	entries, err := os.ReadDir(dayPath)
+	if err != nil {
+		return 0, err
+	}
+
+	pruned := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name == "manifest.json" || strings.HasSuffix(name, ".json") {
+			continue
+		}
+		if keep[name] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dayPath, name)); err != nil {
+			continue
+		}
+		_ = os.Remove(filepath.Join(dayPath, name+".json"))
+		pruned++
+	}
+	return pruned, nil
+}
+
+// copyFileContents is a dependency-free byte copy used to archive a blob.
+// Unlike SyncManager.copyFile it doesn't preserve mod times - an archived
+// blob's whole point is to be an immutable, content-addressed snapshot.
+func copyFileContents(srcPath, destPath string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dest, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+
+	_, err = io.Copy(dest, src)
+	return err
+}
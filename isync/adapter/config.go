@@ -15,25 +15,140 @@ type Config struct {
 	SyncInterval       int    `json:"sync_interval"`
 	LogLevel           string `json:"log_level"`
 	BackupEnabled      bool   `json:"backup_enabled"`
+	DebounceWindowMs   int    `json:"debounce_window_ms"`
+
+	// IgnoreGlobs are filepath.Match patterns checked against the base name
+	// of every directory and file the watcher sees; a match is skipped.
+	// IncludeGlobs, if non-empty, acts as an allow-list applied to files
+	// only (directories are still walked so files further down can match).
+	IgnoreGlobs  []string `json:"ignore_globs"`
+	IncludeGlobs []string `json:"include_globs"`
+
+	// WatchMode selects the Watcher backend: "fsnotify" (default) relies on
+	// OS-level filesystem notifications, "poll" walks the watched roots
+	// every PollIntervalSeconds and diffs against a snapshot, and "hybrid"
+	// runs both and deduplicates their output.
+	WatchMode           string `json:"watch_mode"`
+	PollIntervalSeconds int    `json:"poll_interval_seconds"`
+
+	// WatcherBackend selects the OS notification API FileWatcher's
+	// "fsnotify" watch mode uses: "fsnotify" (default) adds each directory
+	// individually via the fsnotify library, while "notify" delegates to
+	// github.com/rjeczalik/notify for a native recursive watch and richer
+	// per-event metadata (see NotifyWatcher). Only consulted when WatchMode
+	// is "" or "fsnotify"; poll and hybrid always use the polling snapshot.
+	WatcherBackend string `json:"watcher_backend"`
+
+	// LogFormat selects the logger's output layout: "text" (default) for
+	// the "[ts] LEVEL file:line - msg | k=v" layout, or "json" for shipping
+	// to log aggregators.
+	LogFormat string `json:"log_format"`
+
+	// JournalPath is where SyncManager's EventJournal persists events
+	// before delivery and the reconciliation snapshot between runs. Empty
+	// defaults to ~/.tm-isync/journal.jsonl (see getJournalPath).
+	JournalPath string `json:"journal_path"`
+
+	// Providers names the storage backends available for off-site
+	// mirroring (see Provider). SyncDestinations lists keys into this map
+	// that every successfully uploaded iCloud file is additionally copied
+	// to, on a best-effort basis, after the primary server upload.
+	Providers        map[string]ProviderConfig `json:"providers,omitempty"`
+	SyncDestinations []string                  `json:"sync_destinations,omitempty"`
+
+	// RetentionDays bounds how long superseded blob versions are kept in
+	// the local content-addressed archive (see BlobStore). 0 (default)
+	// disables pruning, keeping every version forever; the current
+	// version for a path is never pruned regardless of this setting.
+	RetentionDays int `json:"retention_days"`
+
+	// LockTimeoutSeconds bounds how long a sync run waits to acquire the
+	// cross-process run lock (see Locker) before skipping that run. 0
+	// (default) uses defaultLockTimeout.
+	LockTimeoutSeconds int `json:"lock_timeout_seconds"`
+
+	// StaleLockSeconds bounds how long a run lock's heartbeat file can go
+	// unrefreshed before Locker treats it as abandoned by a crashed
+	// process and breaks it (see Locker.breakIfStale). Because the lock
+	// directory is itself inside the iCloud-synced folder, this must
+	// comfortably exceed worst-case iCloud Drive propagation latency
+	// between hosts, not just a multiple of the local heartbeat interval -
+	// 0 (default) uses defaultStaleLock.
+	StaleLockSeconds int `json:"stale_lock_seconds"`
+
+	// MaxConcurrentUploads and MaxConcurrentCopies bound the worker pools
+	// uploadICloudFiles and syncTMToICloud fan file-level work out onto
+	// (see workerPool). 0 (default) uses 5 and 10 respectively.
+	MaxConcurrentUploads int `json:"max_concurrent_uploads"`
+	MaxConcurrentCopies  int `json:"max_concurrent_copies"`
+
+	// AdminAddr is the bind address for the admin/metrics HTTP server
+	// (see AdminServer) - e.g. "127.0.0.1:9090". Empty (default) disables
+	// it. Overridable at runtime with -admin-addr.
+	AdminAddr string `json:"admin_addr"`
+
+	// RestartBackoffSeconds, MaxRestarts and RestartWindowSeconds configure
+	// the supervisor (see newSupervisor) that restarts the sync manager,
+	// status reporter and admin server independently if one of them panics
+	// or returns unexpectedly. When failures, decaying with a half-life of
+	// RestartWindowSeconds, exceed MaxRestarts, restarts pause for
+	// RestartBackoffSeconds and then resume; repeated failures do not stop
+	// the supervisor or the process. All default when unset
+	// (see defaultRestartBackoff, defaultMaxRestarts, defaultRestartWindow).
+	RestartBackoffSeconds int `json:"restart_backoff_seconds"`
+	MaxRestarts           int `json:"max_restarts"`
+	RestartWindowSeconds  int `json:"restart_window_seconds"`
+
+	// UpgradeManifestURL points at a signed ReleaseManifest (see
+	// CheckForUpgrade). Empty (default) disables both the -upgrade flag's
+	// usefulness and the background checker below.
+	UpgradeManifestURL string `json:"upgrade_manifest_url"`
+
+	// AutoUpgrade enables a background goroutine that polls
+	// UpgradeManifestURL every UpgradeCheckInterval seconds (0 default
+	// uses defaultUpgradeCheckInterval) and, on finding a newer signed
+	// release, applies it via ApplyUpgrade and exits with exitUpgrading
+	// so the service wrapper re-launches the new binary.
+	AutoUpgrade         bool `json:"auto_upgrade"`
+	UpgradeCheckInterval int `json:"upgrade_check_interval_seconds"`
+
+	// AuditLog configures the append-only JSON event log SyncManager
+	// records every upload/download/delete/conflict/error to (see
+	// AuditLogger). AuditLog.Path empty (default) disables it.
+	AuditLog AuditLogConfig `json:"audit_log,omitempty"`
 }
 
 // DefaultConfig returns a configuration with sensible defaults
 func DefaultConfig() *Config {
 	return &Config{
-		ICloudParentFolder: "TM_Cases",
-		ApiEndpoint:        "http://localhost:8000/api/icloud/upload",
-		ApiKey:             "your_api_key_here",
-		SyncInterval:       10,
-		LogLevel:           "info",
-		BackupEnabled:      true,
+		ICloudParentFolder:  "TM_Cases",
+		ApiEndpoint:         "http://localhost:8000/api/icloud/upload",
+		ApiKey:              "your_api_key_here",
+		SyncInterval:        10,
+		LogLevel:            "info",
+		BackupEnabled:       true,
+		DebounceWindowMs:    750,
+		WatchMode:           "fsnotify",
+		PollIntervalSeconds: 30,
+		WatcherBackend:       "fsnotify",
+		LogFormat:            "text",
+		LockTimeoutSeconds:    30,
+		StaleLockSeconds:      600,
+		MaxConcurrentUploads:  5,
+		MaxConcurrentCopies:   10,
+		RestartBackoffSeconds: 5,
+		MaxRestarts:           5,
+		RestartWindowSeconds:  30,
 	}
 }
 
 // LoadConfig loads configuration from a JSON file
 func LoadConfig(configPath string) (*Config, error) {
+	log := logger.With("component", "config")
+
 	// Check if config file exists
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		logger.Warn("Config file not found, creating default config", "path", configPath)
+		log.Warn("Config file not found, creating default config", "path", configPath)
 		config := DefaultConfig()
 		if err := SaveConfig(config, configPath); err != nil {
 			return nil, fmt.Errorf("failed to create default config: %w", err)
@@ -58,7 +173,7 @@ func LoadConfig(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	logger.Info("Configuration loaded successfully", "path", configPath)
+	log.Info("Configuration loaded successfully", "path", configPath)
 	return &config, nil
 }
 
@@ -102,6 +217,59 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("sync_interval must be at least 1 second")
 	}
 
+	if config.DebounceWindowMs < 0 {
+		return fmt.Errorf("debounce_window_ms cannot be negative")
+	}
+
+	if config.LockTimeoutSeconds < 0 {
+		return fmt.Errorf("lock_timeout_seconds cannot be negative")
+	}
+
+	if config.StaleLockSeconds < 0 {
+		return fmt.Errorf("stale_lock_seconds cannot be negative")
+	}
+
+	if config.MaxConcurrentUploads < 0 {
+		return fmt.Errorf("max_concurrent_uploads cannot be negative")
+	}
+	if config.MaxConcurrentCopies < 0 {
+		return fmt.Errorf("max_concurrent_copies cannot be negative")
+	}
+
+	if config.RestartBackoffSeconds < 0 {
+		return fmt.Errorf("restart_backoff_seconds cannot be negative")
+	}
+	if config.MaxRestarts < 0 {
+		return fmt.Errorf("max_restarts cannot be negative")
+	}
+	if config.RestartWindowSeconds < 0 {
+		return fmt.Errorf("restart_window_seconds cannot be negative")
+	}
+
+	if config.UpgradeCheckInterval < 0 {
+		return fmt.Errorf("upgrade_check_interval_seconds cannot be negative")
+	}
+
+	if _, err := compileGlobs(config.IgnoreGlobs); err != nil {
+		return fmt.Errorf("ignore_globs: %w", err)
+	}
+	if _, err := compileGlobs(config.IncludeGlobs); err != nil {
+		return fmt.Errorf("include_globs: %w", err)
+	}
+
+	validWatchModes := map[string]bool{"": true, "fsnotify": true, "poll": true, "hybrid": true}
+	if !validWatchModes[config.WatchMode] {
+		return fmt.Errorf("invalid watch_mode: %s (must be fsnotify, poll, or hybrid)", config.WatchMode)
+	}
+	if (config.WatchMode == "poll" || config.WatchMode == "hybrid") && config.PollIntervalSeconds < 1 {
+		return fmt.Errorf("poll_interval_seconds must be at least 1 second for watch_mode %q", config.WatchMode)
+	}
+
+	validWatcherBackends := map[string]bool{"": true, "fsnotify": true, "notify": true}
+	if !validWatcherBackends[config.WatcherBackend] {
+		return fmt.Errorf("invalid watcher_backend: %s (must be fsnotify or notify)", config.WatcherBackend)
+	}
+
 	// Validate log level
 	validLogLevels := map[string]bool{
 		"debug": true,
@@ -113,6 +281,10 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("invalid log_level: %s (must be debug, info, warn, or error)", config.LogLevel)
 	}
 
+	if config.LogFormat != "" && config.LogFormat != "text" && config.LogFormat != "json" {
+		return fmt.Errorf("invalid log_format: %s (must be text or json)", config.LogFormat)
+	}
+
 	return nil
 }
 
@@ -133,6 +305,20 @@ func (c *Config) getICloudPath() (string, error) {
 	return icloudPath, nil
 }
 
+// getJournalPath returns the path to the event journal file, honoring
+// Config.JournalPath if set.
+func (c *Config) getJournalPath() (string, error) {
+	if c.JournalPath != "" {
+		return c.JournalPath, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".tm-isync", "journal.jsonl"), nil
+}
+
 // getOutputPath returns the path to TM outputs directory
 func (c *Config) getOutputPath() (string, error) {
     homeDir, err := os.UserHomeDir()
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// compileGlobs validates every pattern in patterns with filepath.Match so
+// bad globs are caught at config-load time rather than surfacing as a
+// silent "never matches" during sync. It returns patterns unchanged (there
+// is nothing cheaper than filepath.Match to precompile a glob into in the
+// standard library) so callers can store the validated slice once instead
+// of re-validating it on every file event.
+func compileGlobs(patterns []string) ([]string, error) {
+	for i, pattern := range patterns {
+		if _, err := filepath.Match(pattern, "probe"); err != nil {
+			return nil, fmt.Errorf("invalid glob pattern at index %d (%q): %w", i, pattern, err)
+		}
+	}
+	return patterns, nil
+}
+
+// matchesAnyGlob reports whether name matches any of patterns.
+func matchesAnyGlob(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, err := filepath.Match(pattern, name); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// dedupWindow is how long HybridWatcher remembers an emitted (path, op)
+// pair, so the same physical change reported by both the fsnotify and
+// polling watchers within the window is only forwarded once.
+const dedupWindow = 2 * time.Second
+
+// HybridWatcher runs a fsnotify-backed FileWatcher and a PollingFileWatcher
+// concurrently and merges their output, so a change is still observed even
+// if one backend misses it - which happens often with fsnotify on iCloud
+// Drive, and with bind/network mounts where inotify/FSEvents delivery is
+// flaky.
+type HybridWatcher struct {
+	fsWatcher   *FileWatcher
+	pollWatcher *PollingFileWatcher
+	eventChan   chan FileEvent
+	done        chan bool
+	log         *Logger
+
+	seenMu sync.Mutex
+	seen   map[string]time.Time
+}
+
+// NewHybridWatcher creates a watcher that merges fsnotify and polling.
+func NewHybridWatcher(config *Config) (*HybridWatcher, error) {
+	fsWatcher, err := NewFileWatcher(config)
+	if err != nil {
+		return nil, err
+	}
+	pollWatcher, err := NewPollingFileWatcher(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HybridWatcher{
+		fsWatcher:   fsWatcher,
+		pollWatcher: pollWatcher,
+		eventChan:   make(chan FileEvent, 100),
+		done:        make(chan bool),
+		log:         logger.With("component", "watcher.hybrid"),
+		seen:        make(map[string]time.Time),
+	}, nil
+}
+
+// Start starts both backing watchers and the merge loop.
+func (hw *HybridWatcher) Start(ctx context.Context) error {
+	if err := hw.fsWatcher.Start(ctx); err != nil {
+		return err
+	}
+	if err := hw.pollWatcher.Start(ctx); err != nil {
+		return err
+	}
+
+	go hw.merge(ctx, hw.fsWatcher.GetEventChannel())
+	go hw.merge(ctx, hw.pollWatcher.GetEventChannel())
+
+	hw.log.Info("Hybrid watcher started")
+	return nil
+}
+
+// merge forwards events from src into hw.eventChan, dropping any (path, op)
+// pair already forwarded within dedupWindow.
+func (hw *HybridWatcher) merge(ctx context.Context, src <-chan FileEvent) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hw.done:
+			return
+		case event, ok := <-src:
+			if !ok {
+				return
+			}
+			if hw.shouldForward(event) {
+				select {
+				case hw.eventChan <- event:
+				default:
+					hw.log.Warn("Hybrid event channel full, dropping event", "path", event.Path)
+				}
+			}
+		}
+	}
+}
+
+func (hw *HybridWatcher) shouldForward(event FileEvent) bool {
+	key := event.Path + "|" + event.Operation
+
+	hw.seenMu.Lock()
+	defer hw.seenMu.Unlock()
+
+	now := time.Now()
+	for k, at := range hw.seen {
+		if now.Sub(at) > dedupWindow {
+			delete(hw.seen, k)
+		}
+	}
+
+	if at, exists := hw.seen[key]; exists && now.Sub(at) <= dedupWindow {
+		return false
+	}
+	hw.seen[key] = now
+	return true
+}
+
+// GetEventChannel returns the channel for receiving merged file events.
+func (hw *HybridWatcher) GetEventChannel() <-chan FileEvent {
+	return hw.eventChan
+}
+
+// Stop stops both backing watchers.
+func (hw *HybridWatcher) Stop() error {
+	hw.log.Info("Stopping hybrid watcher")
+	close(hw.done)
+
+	if err := hw.fsWatcher.Stop(); err != nil {
+		return err
+	}
+	return hw.pollWatcher.Stop()
+}
+
+var _ Watcher = (*HybridWatcher)(nil)
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// JournalEntry is one line of the on-disk journal: a FileEvent plus the
+// monotonic sequence number SyncManager acks once the event has been fully
+// processed.
+type JournalEntry struct {
+	Seq       int64     `json:"seq"`
+	Event     FileEvent `json:"event"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// EventJournal persists every FileEvent to an append-only JSON-lines file
+// before it's handed to a consumer, so a crash between delivery and
+// processing doesn't silently lose it - restarting replays anything whose
+// seq is still above the acked cursor. The cursor is a second small file
+// holding the highest acked seq, rewritten atomically on every Ack.
+//
+// This trades the stronger durability of an embedded database (e.g.
+// BoltDB) for zero new dependencies, consistent with how the rest of this
+// adapter persists state (see SaveConfig) - acceptable here since the
+// journal only needs to survive process restarts, not concurrent writers.
+type EventJournal struct {
+	mu         sync.Mutex
+	path       string
+	cursorPath string
+	file       *os.File
+	nextSeq    int64
+	ackedSeq   int64
+	log        *Logger
+}
+
+// NewEventJournal opens (creating if necessary) the journal file at path
+// and its companion cursor file, and determines the next sequence number
+// from the journal's existing entries and the acked cursor.
+func NewEventJournal(path string) (*EventJournal, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create journal directory: %w", err)
+	}
+
+	j := &EventJournal{
+		path:       path,
+		cursorPath: path + ".cursor",
+		log:        logger.With("component", "journal"),
+	}
+
+	entries, err := j.readAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read existing journal: %w", err)
+	}
+	for _, e := range entries {
+		if e.Seq >= j.nextSeq {
+			j.nextSeq = e.Seq + 1
+		}
+	}
+
+	if data, err := os.ReadFile(j.cursorPath); err == nil {
+		fmt.Sscanf(string(data), "%d", &j.ackedSeq)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read journal cursor: %w", err)
+	}
+
+	// A seq at or below the acked cursor would be skipped on replay, so new
+	// entries always start above it (compaction may have emptied the file).
+	if j.nextSeq <= j.ackedSeq {
+		j.nextSeq = j.ackedSeq + 1
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal for append: %w", err)
+	}
+	j.file = file
+
+	return j, nil
+}
+
+// Append persists event and returns the sequence number it was assigned.
+func (j *EventJournal) Append(event FileEvent) (int64, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	seq := j.nextSeq
+	j.nextSeq++
+
+	entry := JournalEntry{Seq: seq, Event: event, Timestamp: time.Now()}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal journal entry: %w", err)
+	}
+
+	if _, err := j.file.Write(append(data, '\n')); err != nil {
+		return 0, fmt.Errorf("failed to write journal entry: %w", err)
+	}
+
+	return seq, nil
+}
+
+// Ack records seq as processed. Acks are monotonic: acking an older seq
+// than what's already recorded is a no-op, since entries are always
+// replayed and acked in order.
+func (j *EventJournal) Ack(seq int64) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if seq <= j.ackedSeq {
+		return nil
+	}
+	j.ackedSeq = seq
+
+	tmpPath := j.cursorPath + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(fmt.Sprintf("%d", seq)), 0644); err != nil {
+		return fmt.Errorf("failed to write journal cursor: %w", err)
+	}
+	return os.Rename(tmpPath, j.cursorPath)
+}
+
+// ReplayUnacked returns every journaled entry with a seq above the acked
+// cursor, in the order they were originally appended.
+func (j *EventJournal) ReplayUnacked() ([]JournalEntry, error) {
+	entries, err := j.readAll()
+	if err != nil {
+		return nil, err
+	}
+
+	j.mu.Lock()
+	acked := j.ackedSeq
+	j.mu.Unlock()
+
+	var unacked []JournalEntry
+	for _, e := range entries {
+		if e.Seq > acked {
+			unacked = append(unacked, e)
+		}
+	}
+	return unacked, nil
+}
+
+// ReplayFrom returns every journaled entry timestamped at or after since,
+// regardless of ack state - used by the --replay-from CLI flag for manual
+// re-processing.
+func (j *EventJournal) ReplayFrom(since time.Time) ([]JournalEntry, error) {
+	entries, err := j.readAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []JournalEntry
+	for _, e := range entries {
+		if !e.Timestamp.Before(since) {
+			matched = append(matched, e)
+		}
+	}
+	return matched, nil
+}
+
+func (j *EventJournal) readAll() ([]JournalEntry, error) {
+	f, err := os.Open(j.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []JournalEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			j.log.Warn("Skipping malformed journal line", "error", err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// compactThreshold is the minimum number of acked-and-therefore-prunable
+// entries that must have accumulated before Compact bothers rewriting the
+// journal file, so a periodic call to it is a cheap no-op until there's
+// actually something worth reclaiming.
+const compactThreshold = 1000
+
+// Compact rewrites the journal file to retain only entries above the
+// acked cursor, discarding everything already fully processed. Without
+// this, an always-on daemon restarted repeatedly (see the supervisor in
+// supervisor.go) accumulates an append-only file that NewEventJournal and
+// ReplayFrom must fully re-read on every startup/replay, growing slower
+// without bound. Safe to call periodically.
+func (j *EventJournal) Compact() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	// readAll must run under j.mu, not before it - otherwise an Append
+	// landing between the read and the lock is written to the live file
+	// but absent from entries/kept below, and the rename that follows
+	// permanently drops it.
+	entries, err := j.readAll()
+	if err != nil {
+		return fmt.Errorf("failed to read journal for compaction: %w", err)
+	}
+
+	var kept []JournalEntry
+	pruned := 0
+	for _, e := range entries {
+		if e.Seq > j.ackedSeq {
+			kept = append(kept, e)
+		} else {
+			pruned++
+		}
+	}
+	if pruned < compactThreshold {
+		return nil
+	}
+
+	tmpPath := j.path + ".compact"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create compacted journal: %w", err)
+	}
+
+	w := bufio.NewWriter(f)
+	for _, e := range kept {
+		data, err := json.Marshal(e)
+		if err != nil {
+			f.Close()
+			os.Remove(tmpPath)
+			return fmt.Errorf("failed to marshal journal entry during compaction: %w", err)
+		}
+		if _, err := w.Write(append(data, '\n')); err != nil {
+			f.Close()
+			os.Remove(tmpPath)
+			return fmt.Errorf("failed to write compacted journal: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to flush compacted journal: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to close compacted journal: %w", err)
+	}
+
+	if err := j.file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to close journal before compaction swap: %w", err)
+	}
+	if err := os.Rename(tmpPath, j.path); err != nil {
+		return fmt.Errorf("failed to swap in compacted journal: %w", err)
+	}
+
+	file, err := os.OpenFile(j.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to reopen journal after compaction: %w", err)
+	}
+	j.file = file
+
+	j.log.Info("Compacted event journal", "entries_kept", len(kept), "entries_pruned", pruned)
+	return nil
+}
+
+// Close closes the underlying journal file.
+func (j *EventJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.file.Close()
+}
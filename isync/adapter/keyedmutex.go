@@ -0,0 +1,31 @@
+package main
+
+import "sync"
+
+// keyedMutex hands out a lock per key, so callers touching different keys
+// (e.g. different relative paths) don't serialize behind one another while
+// callers touching the same key still do. Used to replace SyncManager's
+// old single sm.syncing bool guard, which blocked unrelated files behind
+// whichever one happened to be syncing first.
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// Lock blocks until key's lock is free, then returns a func that releases
+// it. The zero value of keyedMutex is ready to use.
+func (k *keyedMutex) Lock(key string) func() {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = make(map[string]*sync.Mutex)
+	}
+	l, ok := k.locks[key]
+	if !ok {
+		l = &sync.Mutex{}
+		k.locks[key] = l
+	}
+	k.mu.Unlock()
+
+	l.Lock()
+	return l.Unlock
+}
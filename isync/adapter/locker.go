@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+// defaultLockTimeout is used when Config.LockTimeoutSeconds is unset.
+const defaultLockTimeout = 30 * time.Second
+
+// heartbeatInterval is how often a held Locker refreshes its heartbeat
+// file.
+const heartbeatInterval = 10 * time.Second
+
+// defaultStaleLock is used when Config.StaleLockSeconds is unset. It's
+// set well above any realistic local crash-detection interval because the
+// heartbeat file lives in the iCloud-synced directory itself: a second
+// host can easily see a heartbeat that's 1-2 minutes stale purely from
+// iCloud Drive propagation lag, not an actual crash. Breaking the lock
+// early on a remote host while the original process is still healthy and
+// running is exactly the concurrent-run corruption this lock exists to
+// prevent, so this errs on the side of a slow takeover over a false one.
+const defaultStaleLock = 10 * time.Minute
+
+// Locker provides an exclusive lock over a directory shared by every
+// tm-icloud-sync daemon pointed at it - e.g. the same iCloud Drive synced
+// to both a paralegal's laptop and desktop - so only one of them performs
+// a sync run at a time. It combines an OS-level advisory lock
+// (github.com/gofrs/flock, exclusive within one host) with a heartbeat
+// file visible to every host sharing the synced folder, so a lock left
+// behind by a process that crashed mid-run can be broken once its
+// heartbeat has gone stale for longer than staleAfter.
+type Locker struct {
+	lockPath      string
+	heartbeatPath string
+	staleAfter    time.Duration
+
+	mu   sync.Mutex
+	fl   *flock.Flock
+	stop chan struct{}
+}
+
+// NewLocker returns a Locker guarding dir/.tm-sync/run.lock. staleAfter
+// bounds how long the heartbeat file can go unrefreshed before the lock is
+// considered abandoned (see defaultStaleLock); staleAfter <= 0 uses
+// defaultStaleLock.
+func NewLocker(dir string, staleAfter time.Duration) *Locker {
+	if staleAfter <= 0 {
+		staleAfter = defaultStaleLock
+	}
+	lockPath := filepath.Join(dir, manifestSidecarDir, "run.lock")
+	return &Locker{
+		lockPath:      lockPath,
+		heartbeatPath: lockPath + ".heartbeat",
+		staleAfter:    staleAfter,
+	}
+}
+
+// Acquire attempts to take the exclusive lock within timeout, first
+// breaking the lock if its heartbeat has gone stale. It returns false
+// (with a nil error) if the lock is held by someone else and still
+// healthy when timeout elapses - callers should skip the run rather than
+// queue it.
+func (l *Locker) Acquire(timeout time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.lockPath), 0755); err != nil {
+		return false, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	l.breakIfStale()
+
+	// flock only excludes processes on this host; a heartbeat kept fresh by
+	// a holder on another host sharing the folder also counts as held.
+	deadline := time.Now().Add(timeout)
+	for {
+		info, err := os.Stat(l.heartbeatPath)
+		if err != nil || time.Since(info.ModTime()) > l.staleAfter {
+			break
+		}
+		if time.Now().After(deadline) {
+			return false, nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	fl := flock.New(l.lockPath)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	locked, err := fl.TryLockContext(ctx, 100*time.Millisecond)
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return false, fmt.Errorf("failed to acquire run lock: %w", err)
+	}
+	if !locked {
+		return false, nil
+	}
+
+	l.fl = fl
+	l.stop = make(chan struct{})
+	_ = l.writeHeartbeat()
+	go l.beat()
+
+	return true, nil
+}
+
+// Release drops the lock and stops the heartbeat.
+func (l *Locker) Release() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.stop != nil {
+		close(l.stop)
+		l.stop = nil
+	}
+	if l.fl == nil {
+		return nil
+	}
+
+	err := l.fl.Unlock()
+	l.fl = nil
+	_ = os.Remove(l.heartbeatPath)
+	return err
+}
+
+func (l *Locker) beat() {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			_ = l.writeHeartbeat()
+		}
+	}
+}
+
+func (l *Locker) writeHeartbeat() error {
+	return os.WriteFile(l.heartbeatPath, []byte(time.Now().UTC().Format(time.RFC3339)), 0644)
+}
+
+// breakIfStale removes the lock and heartbeat files when the heartbeat
+// hasn't been refreshed in over l.staleAfter, indicating whoever held the
+// lock crashed without releasing it.
+func (l *Locker) breakIfStale() {
+	info, err := os.Stat(l.heartbeatPath)
+	if err != nil {
+		return
+	}
+	if time.Since(info.ModTime()) <= l.staleAfter {
+		return
+	}
+	_ = os.Remove(l.lockPath)
+	_ = os.Remove(l.heartbeatPath)
+}
@@ -1,194 +1,257 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 )
 
-// LogLevel represents different logging levels
-type LogLevel int
+// Logger wraps *slog.Logger so the package-level Debug/Info/Warn/Error
+// helpers and Fatal can be preserved without every call site in the repo
+// needing to change. With returns a scoped child Logger - the normal way to
+// keep Fatal available across slog.Logger.With, which on its own returns a
+// plain *slog.Logger.
+type Logger struct {
+	*slog.Logger
+}
 
-const (
-	DEBUG LogLevel = iota
-	INFO
-	WARN
-	ERROR
-)
+// With returns a child logger carrying the given attributes, e.g.
+// logger.With("component", "watcher") for subsystem-scoped logs that
+// TM_DEBUG can enable independently of the base level.
+func (l *Logger) With(args ...any) *Logger {
+	return &Logger{Logger: l.Logger.With(args...)}
+}
 
-// String returns the string representation of the log level
-func (l LogLevel) String() string {
-	switch l {
-	case DEBUG:
-		return "DEBUG"
-	case INFO:
-		return "INFO"
-	case WARN:
-		return "WARN"
-	case ERROR:
-		return "ERROR"
-	default:
-		return "UNKNOWN"
-	}
+// Fatal logs at error level and exits the process.
+func (l *Logger) Fatal(msg string, args ...any) {
+	l.Logger.Error("FATAL: "+msg, args...)
+	os.Exit(1)
 }
 
-// Logger provides structured logging functionality
-type Logger struct {
-	level  LogLevel
-	logger *log.Logger
+// Global logger instance
+var logger *Logger
+
+// InitLogger initializes the global logger. format selects "json" (for
+// shipping to log aggregators) or the adapter's traditional
+// "[ts] LEVEL file:line - msg | k=v" text layout; anything else falls back
+// to text. Component-scoped debug logging is controlled separately via the
+// TM_DEBUG env var (see parseDebugPatterns).
+func InitLogger(level, format string) {
+	logger = NewLogger(level, format)
+}
+
+// NewLogger creates a logger at the given base level and format.
+func NewLogger(level, format string) *Logger {
+	handler := newTMHandler(os.Stdout, levelFromString(level), format, parseDebugPatterns(os.Getenv("TM_DEBUG")))
+	return &Logger{Logger: slog.New(handler)}
 }
 
-// NewLogger creates a new logger with the specified level
-func NewLogger(levelStr string) *Logger {
-	var level LogLevel
+func levelFromString(levelStr string) slog.Level {
 	switch strings.ToLower(levelStr) {
 	case "debug":
-		level = DEBUG
-	case "info":
-		level = INFO
+		return slog.LevelDebug
 	case "warn":
-		level = WARN
+		return slog.LevelWarn
 	case "error":
-		level = ERROR
+		return slog.LevelError
 	default:
-		level = INFO
+		return slog.LevelInfo
 	}
+}
 
-	return &Logger{
-		level:  level,
-		logger: log.New(os.Stdout, "", 0), // We'll handle our own formatting
+// parseDebugPatterns splits a TM_DEBUG value like "watcher.*,sync.icloud"
+// into glob patterns matched against a logger's "component" attribute, so
+// e.g. TM_DEBUG="watcher.*" enables debug-level logs for every watcher.*
+// component regardless of the configured base log level.
+func parseDebugPatterns(value string) []string {
+	if value == "" {
+		return nil
+	}
+	var patterns []string
+	for _, p := range strings.Split(value, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, p)
+		}
 	}
+	return patterns
 }
 
-// Global logger instance
-var logger *Logger
+// tmHandlerShared is the state common to every tmHandler derived (via
+// WithAttrs) from a single root handler: the output stream, base level,
+// TM_DEBUG patterns, format, and the mutex serializing writes.
+type tmHandlerShared struct {
+	mu       sync.Mutex
+	out      io.Writer
+	level    slog.Level
+	patterns []string
+	format   string
+}
 
-// InitLogger initializes the global logger
-func InitLogger(level string) {
-	logger = NewLogger(level)
-}
-
-// formatMessage formats a log message with timestamp, level, and caller info
-func (l *Logger) formatMessage(level LogLevel, msg string, args ...interface{}) string {
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	
-	// Get caller info
-	_, file, line, _ := runtime.Caller(3) // Skip formatMessage, log method, and public method
-	filename := file[strings.LastIndex(file, "/")+1:]
-	
-	// Format the base message
-	formatted := fmt.Sprintf("[%s] %s %s:%d - %s", 
-		timestamp, level.String(), filename, line, msg)
-	
-	// Add key-value pairs if provided
-	if len(args) > 0 {
-		if len(args)%2 != 0 {
-			// Odd number of args, treat last one as a value with "data" key
-			args = append([]interface{}{"data"}, args...)
-		}
-		
-		var pairs []string
-		for i := 0; i < len(args); i += 2 {
-			key := fmt.Sprintf("%v", args[i])
-			value := fmt.Sprintf("%v", args[i+1])
-			pairs = append(pairs, fmt.Sprintf("%s=%s", key, value))
-		}
-		
-		if len(pairs) > 0 {
-			formatted += " | " + strings.Join(pairs, " ")
-		}
-	}
-	
-	return formatted
+// tmHandler is a slog.Handler implementing component-scoped level
+// filtering on top of a choice of JSON or the adapter's traditional text
+// layout. component is whatever value was bound via
+// logger.With("component", "..."); records below the base level still get
+// through if component matches one of TM_DEBUG's glob patterns.
+type tmHandler struct {
+	shared    *tmHandlerShared
+	component string
+	attrs     []slog.Attr
 }
 
-// log writes a message at the specified level
-func (l *Logger) log(level LogLevel, msg string, args ...interface{}) {
-	if level >= l.level {
-		formatted := l.formatMessage(level, msg, args...)
-		l.logger.Println(formatted)
+func newTMHandler(out io.Writer, level slog.Level, format string, patterns []string) *tmHandler {
+	if format != "json" {
+		format = "text"
 	}
+	return &tmHandler{shared: &tmHandlerShared{out: out, level: level, patterns: patterns, format: format}}
 }
 
-// Debug logs a debug message
-func (l *Logger) Debug(msg string, args ...interface{}) {
-	l.log(DEBUG, msg, args...)
+func (h *tmHandler) Enabled(_ context.Context, level slog.Level) bool {
+	if level >= h.shared.level {
+		return true
+	}
+	if h.component == "" {
+		return false
+	}
+	for _, p := range h.shared.patterns {
+		if matched, err := filepath.Match(p, h.component); err == nil && matched {
+			return true
+		}
+	}
+	return false
 }
 
-// Info logs an info message
-func (l *Logger) Info(msg string, args ...interface{}) {
-	l.log(INFO, msg, args...)
+func (h *tmHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := make([]slog.Attr, 0, len(h.attrs)+r.NumAttrs()+1)
+	if h.component != "" {
+		attrs = append(attrs, slog.String("component", h.component))
+	}
+	attrs = append(attrs, h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+
+	h.shared.mu.Lock()
+	defer h.shared.mu.Unlock()
+
+	if h.shared.format == "json" {
+		return h.handleJSON(r, attrs)
+	}
+	return h.handleText(r, attrs)
 }
 
-// Warn logs a warning message
-func (l *Logger) Warn(msg string, args ...interface{}) {
-	l.log(WARN, msg, args...)
+func (h *tmHandler) handleJSON(r slog.Record, attrs []slog.Attr) error {
+	entry := map[string]any{
+		"time":  r.Time.Format(time.RFC3339),
+		"level": r.Level.String(),
+		"msg":   r.Message,
+	}
+	for _, a := range attrs {
+		entry[a.Key] = a.Value.Any()
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(h.shared.out, string(data))
+	return err
 }
 
-// Error logs an error message
-func (l *Logger) Error(msg string, args ...interface{}) {
-	l.log(ERROR, msg, args...)
+func (h *tmHandler) handleText(r slog.Record, attrs []slog.Attr) error {
+	file, line := callerLocation(r.PC)
+	formatted := fmt.Sprintf("[%s] %s %s:%d - %s",
+		r.Time.Format("2006-01-02 15:04:05"), r.Level.String(), file, line, r.Message)
+
+	if len(attrs) > 0 {
+		pairs := make([]string, 0, len(attrs))
+		for _, a := range attrs {
+			pairs = append(pairs, fmt.Sprintf("%s=%v", a.Key, a.Value.Any()))
+		}
+		formatted += " | " + strings.Join(pairs, " ")
+	}
+
+	_, err := fmt.Fprintln(h.shared.out, formatted)
+	return err
 }
 
-// Fatal logs an error message and exits the program
-func (l *Logger) Fatal(msg string, args ...interface{}) {
-	l.log(ERROR, "FATAL: "+msg, args...)
-	os.Exit(1)
+// callerLocation resolves a slog.Record's PC to a "file.go", line pair
+// (base name only), matching what the hand-rolled Logger used to report.
+func callerLocation(pc uintptr) (string, int) {
+	if pc == 0 {
+		return "unknown", 0
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	file := frame.File
+	if idx := strings.LastIndex(file, "/"); idx >= 0 {
+		file = file[idx+1:]
+	}
+	return file, frame.Line
 }
 
-// SetLevel changes the logging level
-func (l *Logger) SetLevel(levelStr string) {
-	switch strings.ToLower(levelStr) {
-	case "debug":
-		l.level = DEBUG
-	case "info":
-		l.level = INFO
-	case "warn":
-		l.level = WARN
-	case "error":
-		l.level = ERROR
+func (h *tmHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := &tmHandler{
+		shared:    h.shared,
+		component: h.component,
+		attrs:     append(append([]slog.Attr{}, h.attrs...), attrs...),
+	}
+	for _, a := range attrs {
+		if a.Key == "component" {
+			next.component = a.Value.String()
+		}
 	}
+	return next
+}
+
+// WithGroup is unsupported: nothing in this codebase groups attributes, so
+// it's a no-op rather than real group nesting.
+func (h *tmHandler) WithGroup(_ string) slog.Handler {
+	return h
 }
 
 // Global convenience functions that use the global logger
 
 // Debug logs a debug message using the global logger
-func Debug(msg string, args ...interface{}) {
+func Debug(msg string, args ...any) {
 	if logger != nil {
 		logger.Debug(msg, args...)
 	}
 }
 
 // Info logs an info message using the global logger
-func Info(msg string, args ...interface{}) {
+func Info(msg string, args ...any) {
 	if logger != nil {
 		logger.Info(msg, args...)
 	}
 }
 
 // Warn logs a warning message using the global logger
-func Warn(msg string, args ...interface{}) {
+func Warn(msg string, args ...any) {
 	if logger != nil {
 		logger.Warn(msg, args...)
 	}
 }
 
 // Error logs an error message using the global logger
-func Error(msg string, args ...interface{}) {
+func Error(msg string, args ...any) {
 	if logger != nil {
 		logger.Error(msg, args...)
 	}
 }
 
 // Fatal logs an error message and exits the program using the global logger
-func Fatal(msg string, args ...interface{}) {
+func Fatal(msg string, args ...any) {
 	if logger != nil {
 		logger.Fatal(msg, args...)
 	} else {
 		fmt.Printf("FATAL: %s\n", msg)
 		os.Exit(1)
 	}
-}
\ No newline at end of file
+}
@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -16,27 +19,51 @@ const (
 	AppVersion = "1.0.0"
 )
 
+// errUpgradeReady is returned by Application.Run when an auto-upgrade has
+// already swapped in a new binary on disk and the process needs to exit
+// (with exitUpgrading) so the service wrapper can re-launch it.
+var errUpgradeReady = errors.New("upgrade applied, restart required")
+
 // Application represents the main application
 type Application struct {
-	config      *Config
-	syncManager *SyncManager
-	ctx         context.Context
-	cancel      context.CancelFunc
+	configPath   string
+	config       *Config
+	syncManager  *SyncManager
+	adminServer  *AdminServer
+	upgradeReady chan *ReleaseManifest
+	ctx          context.Context
+	cancel       context.CancelFunc
+}
+
+// ConfigOverrides holds CLI-flag-provided values that take precedence
+// over config.json. They're applied before NewSyncManager runs so
+// anything resolved eagerly at construction time (e.g. the audit log
+// file) sees the overridden value rather than config.json's.
+type ConfigOverrides struct {
+	AdminAddr string
+	AuditPath string
 }
 
 // NewApplication creates a new application instance
-func NewApplication(configPath string) (*Application, error) {
+func NewApplication(configPath string, overrides ConfigOverrides) (*Application, error) {
 	// Initialize logger with default level first (to prevent crashes in LoadConfig)
-	InitLogger("info")
-	
+	InitLogger("info", "text")
+
 	// Load configuration
 	config, err := LoadConfig(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load configuration: %w", err)
 	}
 
-	// Re-initialize logger with config level
-	InitLogger(config.LogLevel)
+	if overrides.AdminAddr != "" {
+		config.AdminAddr = overrides.AdminAddr
+	}
+	if overrides.AuditPath != "" {
+		config.AuditLog.Path = overrides.AuditPath
+	}
+
+	// Re-initialize logger with config level and format
+	InitLogger(config.LogLevel, config.LogFormat)
 
 	// Create sync manager
 	syncManager, err := NewSyncManager(config)
@@ -48,39 +75,80 @@ func NewApplication(configPath string) (*Application, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &Application{
-		config:      config,
-		syncManager: syncManager,
-		ctx:         ctx,
-		cancel:      cancel,
+		configPath:   configPath,
+		config:       config,
+		syncManager:  syncManager,
+		upgradeReady: make(chan *ReleaseManifest, 1),
+		ctx:          ctx,
+		cancel:       cancel,
 	}, nil
 }
 
-// Start starts the application
-func (app *Application) Start() error {
-	logger.Info("Starting TM iSync Adapter", "version", AppVersion)
-	
-	// Log configuration
-	logger.Info("Configuration loaded",
-		"icloud_parent_folder", app.config.ICloudParentFolder,
-		"api_endpoint", app.config.ApiEndpoint,
-		"sync_interval", app.config.SyncInterval,
-		"log_level", app.config.LogLevel,
-		"backup_enabled", app.config.BackupEnabled)
+// runUpgradeChecker polls Config.UpgradeManifestURL every
+// UpgradeCheckInterval seconds while Config.AutoUpgrade is set, and, as
+// soon as a newer signed release has been downloaded and swapped onto
+// disk, signals Run via upgradeReady - Run still owns shutting the
+// supervisor down cleanly before the process exits to re-exec.
+func (app *Application) runUpgradeChecker(ctx context.Context) {
+	if !app.config.AutoUpgrade || app.config.UpgradeManifestURL == "" {
+		return
+	}
 
-	// Validate paths
-	err := app.validatePaths()
-	if err != nil {
-		return fmt.Errorf("path validation failed: %w", err)
+	interval := time.Duration(app.config.UpgradeCheckInterval) * time.Second
+	if interval <= 0 {
+		interval = defaultUpgradeCheckInterval
 	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-	// Start sync manager
-	err = app.syncManager.Start(app.ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			manifest, err := CheckForUpgrade(app.config.UpgradeManifestURL)
+			if err != nil {
+				logger.Error("Upgrade check failed", "error", err)
+				continue
+			}
+			if manifest == nil {
+				continue
+			}
+
+			logger.Info("Newer release available, applying upgrade", "version", manifest.Version)
+			if err := ApplyUpgrade(manifest); err != nil {
+				logger.Error("Failed to apply upgrade", "error", err)
+				continue
+			}
+
+			select {
+			case app.upgradeReady <- manifest:
+			default:
+			}
+			return
+		}
+	}
+}
+
+// reloadConfig re-reads config.json on SIGHUP and applies whichever
+// fields SyncManager can hot-swap without a restart (see
+// SyncManager.ReloadConfig), leaving the running configuration untouched
+// if the reload fails or changes a field that can't be hot-swapped.
+func (app *Application) reloadConfig() {
+	logger.Info("Reloading configuration", "path", app.configPath)
+
+	newConfig, err := LoadConfig(app.configPath)
 	if err != nil {
-		return fmt.Errorf("failed to start sync manager: %w", err)
+		logger.Error("Config reload failed, keeping current configuration", "error", err)
+		return
 	}
 
-	logger.Info("TM iSync Adapter started successfully")
-	return nil
+	if err := app.syncManager.ReloadConfig(newConfig); err != nil {
+		logger.Error("Config reload rejected, keeping current configuration", "error", err)
+		return
+	}
+
+	logger.Info("Configuration reloaded successfully")
 }
 
 // validatePaths validates that required paths exist and are accessible
@@ -113,65 +181,62 @@ func (app *Application) validatePaths() error {
 	return nil
 }
 
-// Stop stops the application gracefully
-func (app *Application) Stop() error {
-	logger.Info("Stopping TM iSync Adapter")
-	
-	// Cancel context to stop all goroutines
-	app.cancel()
-
-	// Stop sync manager
-	err := app.syncManager.Stop()
-	if err != nil {
-		logger.Error("Failed to stop sync manager", "error", err)
-		return err
-	}
+// Run runs the application under a supervisor tree: the sync manager,
+// status reporter, and (if configured) admin server each run as a
+// supervised Service, restarted independently on panic or unexpected
+// return rather than taking the whole process down. A SIGINT/SIGTERM
+// shutdown returns nil; Run returns errUpgradeReady once an auto-upgrade
+// has been applied, and an error if path validation fails. The supervisor
+// does not stop on repeated service failures, so it does not end Run.
+func (app *Application) Run() error {
+	logger.Info("Starting TM iSync Adapter", "version", AppVersion)
 
-	logger.Info("TM iSync Adapter stopped successfully")
-	return nil
-}
+	logger.Info("Configuration loaded",
+		"icloud_parent_folder", app.config.ICloudParentFolder,
+		"api_endpoint", app.config.ApiEndpoint,
+		"sync_interval", app.config.SyncInterval,
+		"log_level", app.config.LogLevel,
+		"backup_enabled", app.config.BackupEnabled)
 
-// Run runs the application with signal handling
-func (app *Application) Run() error {
-	// Start the application
-	err := app.Start()
-	if err != nil {
-		return err
+	if err := app.validatePaths(); err != nil {
+		return fmt.Errorf("path validation failed: %w", err)
 	}
 
-	// Set up signal handling
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	sup := newSupervisor(app)
 
-	// Start status reporter
-	go app.statusReporter()
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
-	// Wait for signal
-	sig := <-sigChan
-	logger.Info("Received signal, shutting down", "signal", sig.String())
+	supDone := make(chan error, 1)
+	go func() { supDone <- sup.Serve(app.ctx) }()
+	go app.runUpgradeChecker(app.ctx)
 
-	// Stop the application
-	return app.Stop()
-}
-
-// statusReporter periodically reports application status
-func (app *Application) statusReporter() {
-	ticker := time.NewTicker(5 * time.Minute) // Report every 5 minutes
-	defer ticker.Stop()
+	logger.Info("TM iSync Adapter started successfully")
 
 	for {
 		select {
-		case <-app.ctx.Done():
-			return
-		case <-ticker.C:
-			stats := app.syncManager.GetStats()
-			logger.Info("Sync status",
-				"files_uploaded", stats.FilesUploaded,
-				"files_downloaded", stats.FilesDownloaded,
-				"directories_synced", stats.DirectoriesSync,
-				"errors", stats.Errors,
-				"last_sync", stats.LastSync.Format("2006-01-02 15:04:05"),
-				"uptime", time.Since(stats.StartTime).String())
+		case sig := <-sigChan:
+			if sig == syscall.SIGHUP {
+				app.reloadConfig()
+				continue
+			}
+			logger.Info("Received signal, shutting down", "signal", sig.String())
+			app.cancel()
+			<-supDone
+			logger.Info("TM iSync Adapter stopped successfully")
+			return nil
+		case manifest := <-app.upgradeReady:
+			logger.Info("Upgrade applied on disk, shutting down to re-exec", "version", manifest.Version)
+			app.cancel()
+			<-supDone
+			return errUpgradeReady
+		case err := <-supDone:
+			if err != nil && !errors.Is(err, context.Canceled) {
+				logger.Error("Supervisor exited after exhausting restarts", "error", err)
+				return err
+			}
+			logger.Info("TM iSync Adapter stopped successfully")
+			return nil
 		}
 	}
 }
@@ -179,75 +244,325 @@ func (app *Application) statusReporter() {
 // printUsage prints usage information
 func printUsage() {
 	fmt.Printf("%s v%s\n", AppName, AppVersion)
-	fmt.Println("Usage: tm-isync-adapter [OPTIONS]")
+	fmt.Println("Usage: tm-isync-adapter <command> [OPTIONS]")
 	fmt.Println()
-	fmt.Println("Options:")
-	fmt.Println("  -config string    Path to configuration file (default: config.json)")
-	fmt.Println("  -version          Show version information")
-	fmt.Println("  -help             Show this help message")
+	fmt.Println("Commands:")
+	fmt.Println("  run              Start the sync daemon (default if no command is given)")
+	fmt.Println("  status           Print SyncStats from a running daemon's admin server")
+	fmt.Println("  healthcheck      Run a local health check, exit 0/1 for orchestrators")
+	fmt.Println("  validate-config  Load and validate config.json, exit non-zero on failure")
+	fmt.Println("  generate-config  Write a commented configuration template to stdout")
+	fmt.Println("  version          Show version information")
+	fmt.Println()
+	fmt.Println("Run options (-config is also accepted by status/validate-config/version, -admin-addr by status):")
+	fmt.Println("  -config string       Path to configuration file (default: config.json)")
+	fmt.Println("  -replay-from string  Re-process journaled events since this RFC3339 time, then exit")
+	fmt.Println("  -admin-addr string   Bind address for the admin/metrics HTTP server, overrides config")
+	fmt.Println("  -upgrade             Check for and apply an available upgrade, then exit")
+	fmt.Println("  -audit string        Path to the structured JSON audit log, overrides config")
 	fmt.Println()
 	fmt.Println("Environment Variables:")
 	fmt.Println("  TM_ISYNC_CONFIG   Path to configuration file")
 	fmt.Println()
 	fmt.Println("Example:")
-	fmt.Println("  tm-isync-adapter -config /path/to/config.json")
+	fmt.Println("  tm-isync-adapter run -config /path/to/config.json")
+	fmt.Println("  tm-isync-adapter status -config /path/to/config.json")
 	fmt.Println()
 }
 
-// printVersion prints version information
-func printVersion() {
+// printVersion prints version information and, if manifestURL is set,
+// whether a newer signed release is available.
+func printVersion(manifestURL string) {
 	fmt.Printf("%s v%s\n", AppName, AppVersion)
 	fmt.Println("Built with Go")
 	fmt.Println("Copyright Â© 2025 Tiger-Monkey Legal Document Processing System")
+
+	if manifestURL == "" {
+		return
+	}
+
+	manifest, err := CheckForUpgrade(manifestURL)
+	if err != nil {
+		fmt.Printf("(could not check for updates: %v)\n", err)
+		return
+	}
+	if manifest == nil {
+		fmt.Println("You are running the latest version.")
+		return
+	}
+	fmt.Printf("A newer version is available: v%s\n", manifest.Version)
+}
+
+// resolveConfigPath applies the TM_ISYNC_CONFIG environment override (if
+// set) and resolves configPath to an absolute path.
+func resolveConfigPath(configPath string) (string, error) {
+	if envConfig := os.Getenv("TM_ISYNC_CONFIG"); envConfig != "" {
+		configPath = envConfig
+	}
+	return filepath.Abs(configPath)
+}
+
+// commands maps each subcommand name to its handler. main falls back to
+// runCommand when the first argument isn't one of these, so existing
+// flag-only invocations (e.g. `tm-isync-adapter -config foo.json`) keep
+// working.
+var commands = map[string]func([]string){
+	"run":             runCommand,
+	"status":          statusCommand,
+	"healthcheck":     healthcheckCommand,
+	"validate-config": validateConfigCommand,
+	"generate-config": generateConfigCommand,
+	"version":         versionCommand,
 }
 
-// main is the application entry point
+// main is the application entry point. It dispatches to a subcommand
+// (run/status/healthcheck/validate-config/generate-config/version) the
+// way a single, flag-only CLI used to dispatch on flags.
 func main() {
-	// Parse command line flags
-	var (
-		configPath = flag.String("config", "config.json", "Path to configuration file")
-		version    = flag.Bool("version", false, "Show version information")
-		help       = flag.Bool("help", false, "Show help message")
-	)
-	flag.Parse()
-
-	// Handle version flag
+	args := os.Args[1:]
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "help", "-help", "--help", "-h":
+			printUsage()
+			return
+		}
+		if cmd, ok := commands[args[0]]; ok {
+			cmd(args[1:])
+			return
+		}
+	}
+
+	runCommand(args)
+}
+
+// runCommand starts the sync daemon. It's also main's fallback when no
+// subcommand is recognized, so it still understands -version/-help for
+// scripts written against the previous flat-flag CLI.
+func runCommand(args []string) {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	configPath := fs.String("config", "config.json", "Path to configuration file")
+	version := fs.Bool("version", false, "Show version information")
+	help := fs.Bool("help", false, "Show this help message")
+	replayFrom := fs.String("replay-from", "", "Re-process journaled events timestamped at or after this RFC3339 time, then exit")
+	adminAddr := fs.String("admin-addr", "", "Bind address for the admin/metrics HTTP server (overrides config)")
+	upgrade := fs.Bool("upgrade", false, "Check for and apply an available upgrade, then exit")
+	auditPath := fs.String("audit", "", "Path to the structured JSON audit log (overrides config)")
+	fs.Parse(args)
+
 	if *version {
-		printVersion()
+		versionCommand(nil)
 		return
 	}
-
-	// Handle help flag
 	if *help {
 		printUsage()
 		return
 	}
 
-	// Check for config path from environment
-	if envConfig := os.Getenv("TM_ISYNC_CONFIG"); envConfig != "" {
-		*configPath = envConfig
-	}
-
-	// Convert to absolute path
-	absConfigPath, err := filepath.Abs(*configPath)
+	absConfigPath, err := resolveConfigPath(*configPath)
 	if err != nil {
 		fmt.Printf("Error: Failed to resolve config path: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitError)
 	}
 
-	// Create application
-	app, err := NewApplication(absConfigPath)
+	app, err := NewApplication(absConfigPath, ConfigOverrides{AdminAddr: *adminAddr, AuditPath: *auditPath})
 	if err != nil {
 		fmt.Printf("Error: Failed to create application: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitError)
+	}
+
+	// Handle manual upgrade flag: check for and apply an available
+	// upgrade immediately, rather than waiting on the background checker.
+	if *upgrade {
+		manifest, err := CheckForUpgrade(app.config.UpgradeManifestURL)
+		if err != nil {
+			fmt.Printf("Error: Upgrade check failed: %v\n", err)
+			os.Exit(exitError)
+		}
+		if manifest == nil {
+			fmt.Println("Already running the latest version.")
+			return
+		}
+		fmt.Printf("Applying upgrade to version %s...\n", manifest.Version)
+		if err := ApplyUpgrade(manifest); err != nil {
+			fmt.Printf("Error: Upgrade failed: %v\n", err)
+			os.Exit(exitError)
+		}
+		fmt.Println("Upgrade applied successfully. Restart the service to run the new version.")
+		os.Exit(exitUpgrading)
+	}
+
+	// Handle manual replay flag: re-process journaled events and exit,
+	// rather than starting the normal watch-and-sync loop.
+	if *replayFrom != "" {
+		since, err := time.Parse(time.RFC3339, *replayFrom)
+		if err != nil {
+			fmt.Printf("Error: Invalid -replay-from time (want RFC3339): %v\n", err)
+			os.Exit(exitError)
+		}
+		if err := app.syncManager.ReplayFrom(since); err != nil {
+			fmt.Printf("Error: Replay failed: %v\n", err)
+			os.Exit(exitError)
+		}
+		return
 	}
 
-	// Run application
 	err = app.Run()
 	if err != nil {
+		if errors.Is(err, errUpgradeReady) {
+			logger.Info("Exiting for upgrade re-exec")
+			os.Exit(exitUpgrading)
+		}
 		logger.Error("Application failed", "error", err)
-		os.Exit(1)
+		os.Exit(exitRestarting)
+	}
+}
+
+// statusCommand connects to a running daemon's admin server and prints
+// its current SyncStats. It requires admin_addr (or -admin-addr) to be
+// configured, since that's the only channel a separate CLI invocation has
+// into the running process.
+func statusCommand(args []string) {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	configPath := fs.String("config", "config.json", "Path to configuration file")
+	adminAddr := fs.String("admin-addr", "", "Admin server address to query (overrides config)")
+	fs.Parse(args)
+
+	absConfigPath, err := resolveConfigPath(*configPath)
+	if err != nil {
+		fmt.Printf("Error: Failed to resolve config path: %v\n", err)
+		os.Exit(exitError)
+	}
+	config, err := LoadConfig(absConfigPath)
+	if err != nil {
+		fmt.Printf("Error: Failed to load configuration: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	addr := config.AdminAddr
+	if *adminAddr != "" {
+		addr = *adminAddr
+	}
+	if addr == "" {
+		fmt.Println("Error: admin_addr is not configured; set it in config.json or pass -admin-addr so status can reach the running daemon")
+		os.Exit(exitError)
+	}
+
+	resp, err := http.Get(fmt.Sprintf("http://%s/stats", addr))
+	if err != nil {
+		fmt.Printf("Error: Could not reach daemon admin server at %s: %v\n", addr, err)
+		os.Exit(exitError)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		fmt.Printf("Error: Daemon admin server returned status %d\n", resp.StatusCode)
+		os.Exit(exitError)
+	}
+
+	var stats SyncStats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		fmt.Printf("Error: Failed to parse daemon response: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	fmt.Printf("Files uploaded:     %d\n", stats.FilesUploaded)
+	fmt.Printf("Files downloaded:   %d\n", stats.FilesDownloaded)
+	fmt.Printf("Directories synced: %d\n", stats.DirectoriesSync)
+	fmt.Printf("Errors:             %d\n", stats.Errors)
+	fmt.Printf("In flight:          %d\n", stats.InFlight)
+	fmt.Printf("Queued:             %d\n", stats.Queued)
+	if !stats.LastSync.IsZero() {
+		fmt.Printf("Last sync:          %s\n", stats.LastSync.Format(time.RFC3339))
+	}
+	if !stats.StartTime.IsZero() {
+		fmt.Printf("Uptime:             %s\n", time.Since(stats.StartTime).Round(time.Second))
+	}
+}
+
+// healthcheckCommand runs HealthCheck() and exits 0/1, for container
+// orchestrators and init systems that expect a plain exit-code probe.
+func healthcheckCommand(args []string) {
+	fs := flag.NewFlagSet("healthcheck", flag.ExitOnError)
+	fs.Parse(args)
+
+	ok, message := HealthCheck()
+	fmt.Println(message)
+	if !ok {
+		os.Exit(exitError)
+	}
+}
+
+// validateConfigCommand loads config.json and runs the same path
+// validation Run performs at startup, without starting the daemon.
+func validateConfigCommand(args []string) {
+	fs := flag.NewFlagSet("validate-config", flag.ExitOnError)
+	configPath := fs.String("config", "config.json", "Path to configuration file")
+	fs.Parse(args)
+
+	absConfigPath, err := resolveConfigPath(*configPath)
+	if err != nil {
+		fmt.Printf("Error: Failed to resolve config path: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	config, err := LoadConfig(absConfigPath)
+	if err != nil {
+		fmt.Printf("Error: Invalid configuration: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	app := &Application{config: config}
+	if err := app.validatePaths(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	fmt.Printf("%s is valid.\n", absConfigPath)
+}
+
+// generateConfigCommand writes a commented configuration template to
+// stdout. The comment block must be stripped (or the JSON body saved to
+// its own file) before use, since config.json itself must be plain JSON.
+func generateConfigCommand(args []string) {
+	fs := flag.NewFlagSet("generate-config", flag.ExitOnError)
+	fs.Parse(args)
+
+	fmt.Println("// tm-isync-adapter configuration template.")
+	fmt.Println("// Strip these comment lines and save the JSON below as config.json,")
+	fmt.Println("// or pass -config <path> to point the daemon elsewhere.")
+	fmt.Println("//")
+	fmt.Println("//   icloud_parent_folder  subfolder of iCloud Drive this daemon syncs")
+	fmt.Println("//   sync_interval         seconds between periodic full syncs")
+	fmt.Println("//   admin_addr            bind address for /healthz /readyz /stats /metrics")
+	fmt.Println("//   audit_log.path        JSON audit trail destination; empty disables it")
+	fmt.Println("//   upgrade_manifest_url  signed release manifest URL for -upgrade/auto_upgrade")
+	fmt.Println("//")
+
+	data, err := json.MarshalIndent(DefaultConfig(), "", "  ")
+	if err != nil {
+		fmt.Printf("Error: Failed to render config template: %v\n", err)
+		os.Exit(exitError)
+	}
+	fmt.Println(string(data))
+}
+
+// versionCommand prints version information, including whether a newer
+// signed release is available if config.json sets upgrade_manifest_url.
+func versionCommand(args []string) {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	configPath := fs.String("config", "config.json", "Path to configuration file")
+	fs.Parse(args)
+
+	manifestURL := ""
+	if absConfigPath, err := resolveConfigPath(*configPath); err == nil {
+		if _, statErr := os.Stat(absConfigPath); statErr == nil {
+			if cfg, err := LoadConfig(absConfigPath); err == nil {
+				manifestURL = cfg.UpgradeManifestURL
+			}
+		}
 	}
+	printVersion(manifestURL)
 }
 
 // Health check function for external monitoring
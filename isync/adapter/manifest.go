@@ -0,0 +1,146 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// chunkSize is the fixed block size manifests hash over, matching the
+// block-exchange approach rsync/syncthing use: 128KB keeps the manifest
+// small for typical scanned-PDF sizes while still letting a one-page edit
+// in a large document skip re-uploading the rest of the file.
+const chunkSize = 128 * 1024
+
+// ChunkHash is the SHA-256 of one chunkSize block of a file.
+type ChunkHash struct {
+	Index int    `json:"index"`
+	Hash  string `json:"hash"`
+}
+
+// FileManifest is a file's content-addressed fingerprint: enough to decide,
+// without transferring the file, whether it changed and which chunks
+// differ from a previously known manifest.
+type FileManifest struct {
+	RelPath  string      `json:"rel_path"`
+	Size     int64       `json:"size"`
+	ModTime  time.Time   `json:"mod_time"`
+	FullHash string      `json:"full_hash"`
+	Chunks   []ChunkHash `json:"chunks"`
+}
+
+// computeManifest streams path once, hashing it as a whole and in
+// chunkSize blocks.
+func computeManifest(relPath, path string) (*FileManifest, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	full := sha256.New()
+	var chunks []ChunkHash
+	buf := make([]byte, chunkSize)
+
+	for index := 0; ; index++ {
+		n, readErr := io.ReadFull(f, buf)
+		if n > 0 {
+			full.Write(buf[:n])
+			chunkSum := sha256.Sum256(buf[:n])
+			chunks = append(chunks, ChunkHash{Index: index, Hash: hex.EncodeToString(chunkSum[:])})
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read file: %w", readErr)
+		}
+	}
+
+	return &FileManifest{
+		RelPath:  relPath,
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+		FullHash: hex.EncodeToString(full.Sum(nil)),
+		Chunks:   chunks,
+	}, nil
+}
+
+// diffChunks returns the indexes of chunks present in local that differ
+// from (or are missing in) remote.
+func diffChunks(local, remote *FileManifest) []int {
+	remoteByIndex := make(map[int]string, len(remote.Chunks))
+	for _, c := range remote.Chunks {
+		remoteByIndex[c.Index] = c.Hash
+	}
+
+	var changed []int
+	for _, c := range local.Chunks {
+		if remoteHash, ok := remoteByIndex[c.Index]; !ok || remoteHash != c.Hash {
+			changed = append(changed, c.Index)
+		}
+	}
+	return changed
+}
+
+// ManifestStore persists the manifests computed for the local side of a
+// sync under a single JSON file, so a restart doesn't have to rehash every
+// file under the watched roots to know what's already been sent.
+type ManifestStore struct {
+	mu        sync.Mutex
+	path      string
+	manifests map[string]FileManifest
+}
+
+// newManifestStore loads (or creates) the manifest index at path.
+func newManifestStore(path string) (*ManifestStore, error) {
+	store := &ManifestStore{path: path, manifests: make(map[string]FileManifest)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("failed to read manifest store: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &store.manifests); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest store: %w", err)
+	}
+	return store, nil
+}
+
+// Get returns the last manifest recorded for relPath, if any.
+func (s *ManifestStore) Get(relPath string) (FileManifest, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.manifests[relPath]
+	return m, ok
+}
+
+// Put records m as relPath's current manifest and persists the store.
+func (s *ManifestStore) Put(relPath string, m FileManifest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.manifests[relPath] = m
+	return s.save()
+}
+
+// save rewrites the manifest store file. Must be called with s.mu held.
+func (s *ManifestStore) save() error {
+	data, err := json.MarshalIndent(s.manifests, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest store: %w", err)
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
@@ -0,0 +1,32 @@
+//go:build darwin
+
+package main
+
+import "github.com/rjeczalik/notify"
+
+// fsEventsFlags_t holds the subset of platform-level metadata NotifyWatcher
+// surfaces on FileEvent. isDir/isFile are filled in by the caller via
+// os.Stat; isSymlink/created/renamed come straight off the FSEvents flags
+// when they're available.
+type fsEventsFlags_t struct {
+	isDir     bool
+	isFile    bool
+	isSymlink bool
+	created   bool
+	renamed   bool
+}
+
+// fsEventsFlags extracts macOS FSEvents flags from a notify.EventInfo's Sys,
+// falling back to the zero value if the concrete type isn't what's expected
+// (e.g. in tests that construct EventInfo themselves).
+func fsEventsFlags(ei notify.EventInfo) fsEventsFlags_t {
+	sys, ok := ei.Sys().(*notify.FSEvent)
+	if !ok {
+		return fsEventsFlags_t{}
+	}
+	return fsEventsFlags_t{
+		isSymlink: sys.Flags&notify.FSEventsIsSymlink != 0,
+		created:   sys.Flags&notify.FSEventsItemCreated != 0,
+		renamed:   sys.Flags&notify.FSEventsItemRenamed != 0,
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !darwin
+
+package main
+
+import "github.com/rjeczalik/notify"
+
+// fsEventsFlags_t holds the subset of platform-level metadata NotifyWatcher
+// surfaces on FileEvent. Only macOS FSEvents exposes isSymlink/created/
+// renamed directly; on other platforms these stay false and callers fall
+// back to the regular Operation/Ops fields.
+type fsEventsFlags_t struct {
+	isDir     bool
+	isFile    bool
+	isSymlink bool
+	created   bool
+	renamed   bool
+}
+
+// fsEventsFlags is a no-op outside macOS: notify's Windows and Linux
+// backends don't expose the same Sys() metadata FSEvents does.
+func fsEventsFlags(ei notify.EventInfo) fsEventsFlags_t {
+	return fsEventsFlags_t{}
+}
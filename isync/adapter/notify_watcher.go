@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rjeczalik/notify"
+)
+
+// NotifyWatcher watches the configured iCloud and outputs directories using
+// github.com/rjeczalik/notify instead of fsnotify. Unlike FileWatcher, which
+// walks the tree at startup and races to Add newly created subdirectories in
+// handleEvent, notify's "path/..." form registers a single native recursive
+// watch per root (FSEvents on macOS, ReadDirectoryChangesW on Windows,
+// inotify emulation on Linux), so files created inside a brand-new
+// subdirectory are never missed. It also carries richer per-event metadata
+// than fsnotify exposes, surfaced on FileEvent's FSEvents* fields.
+type NotifyWatcher struct {
+	config    *Config
+	eventChan chan FileEvent
+	done      chan bool
+	filter    *eventFilter
+	log       *Logger
+
+	notifyChan chan notify.EventInfo
+	roots      []string
+}
+
+// NewNotifyWatcher creates a watcher backed by github.com/rjeczalik/notify.
+func NewNotifyWatcher(config *Config) (*NotifyWatcher, error) {
+	filter, err := newEventFilter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NotifyWatcher{
+		config:     config,
+		eventChan:  make(chan FileEvent, 100),
+		done:       make(chan bool),
+		filter:     filter,
+		log:        logger.With("component", "watcher.notify"),
+		notifyChan: make(chan notify.EventInfo, 100),
+	}, nil
+}
+
+// Stats returns a snapshot of the watcher's event classification counters.
+func (nw *NotifyWatcher) Stats() WatcherStats {
+	return nw.filter.Stats()
+}
+
+// Start registers recursive watches on the configured iCloud and outputs
+// directories and begins translating notify.EventInfo into FileEvents.
+func (nw *NotifyWatcher) Start(ctx context.Context) error {
+	icloudPath, err := nw.config.getICloudPath()
+	if err != nil {
+		return fmt.Errorf("failed to get iCloud path: %w", err)
+	}
+	nw.roots = []string{icloudPath}
+
+	if outputPath, err := nw.config.getOutputPath(); err != nil {
+		nw.log.Warn("Could not get output path, reverse sync might not work", "error", err)
+	} else if _, err := os.Stat(outputPath); err == nil {
+		nw.roots = append(nw.roots, outputPath)
+	}
+
+	for _, root := range nw.roots {
+		if err := notify.Watch(filepath.Join(root, "..."), nw.notifyChan, notify.All); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", root, err)
+		}
+	}
+
+	go nw.processEvents(ctx)
+	nw.log.Info("Notify watcher started", "roots", nw.roots)
+	return nil
+}
+
+// processEvents translates notify.EventInfo into FileEvents until Stop or
+// ctx is cancelled.
+func (nw *NotifyWatcher) processEvents(ctx context.Context) {
+	defer close(nw.eventChan)
+
+	for {
+		select {
+		case <-ctx.Done():
+			nw.log.Info("Notify watcher stopped")
+			return
+		case <-nw.done:
+			nw.log.Info("Notify watcher shutdown requested")
+			return
+		case ei, ok := <-nw.notifyChan:
+			if !ok {
+				return
+			}
+			nw.handleEvent(ei)
+		}
+	}
+}
+
+func (nw *NotifyWatcher) handleEvent(ei notify.EventInfo) {
+	path := ei.Path()
+
+	info, statErr := os.Stat(path)
+	isDir := statErr == nil && info.IsDir()
+
+	if isDir {
+		if nw.filter.shouldSkipDirectory(path) {
+			return
+		}
+	} else if nw.filter.shouldSkipFile(path) {
+		return
+	}
+
+	flags := fsEventsFlags(ei)
+	if statErr == nil {
+		flags.isDir = isDir
+		flags.isFile = !isDir
+	}
+
+	fileEvent := FileEvent{
+		Path:      path,
+		Operation: ei.Event().String(),
+		Ops:       []string{ei.Event().String()},
+		IsDir:     isDir,
+		Timestamp: time.Now(),
+
+		FSEventsIsDir:     flags.isDir,
+		FSEventsIsFile:    flags.isFile,
+		FSEventsIsSymlink: flags.isSymlink,
+		FSEventsCreated:   flags.created,
+		FSEventsRenamed:   flags.renamed,
+	}
+
+	nw.log.Debug("Notify event detected", "path", path, "operation", fileEvent.Operation)
+
+	select {
+	case nw.eventChan <- fileEvent:
+	default:
+		nw.log.Warn("Event channel full, dropping event", "path", path)
+		nw.filter.recordDrop()
+	}
+}
+
+// GetEventChannel returns the channel for receiving file events.
+func (nw *NotifyWatcher) GetEventChannel() <-chan FileEvent {
+	return nw.eventChan
+}
+
+// Stop unregisters the recursive watches and stops the watcher.
+func (nw *NotifyWatcher) Stop() error {
+	nw.log.Info("Stopping notify watcher")
+	notify.Stop(nw.notifyChan)
+	close(nw.done)
+	return nil
+}
+
+var _ Watcher = (*NotifyWatcher)(nil)
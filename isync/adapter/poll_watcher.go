@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// fileSnapshot is a PollingFileWatcher's record of one path's on-disk
+// state, used to detect changes between poll ticks.
+type fileSnapshot struct {
+	size    int64
+	modTime time.Time
+	mode    os.FileMode
+	isDir   bool
+}
+
+// PollingFileWatcher produces FileEvents by periodically walking its
+// watched roots and diffing against an in-memory snapshot, rather than
+// relying on OS-level filesystem notifications. It exists because fsnotify
+// on macOS routinely misses events for iCloud Drive: files delivered from
+// the cloud often materialize as ".icloud" placeholders that later swap to
+// real content with no corresponding fsnotify event, and network-backed
+// mounts drop events outright.
+type PollingFileWatcher struct {
+	config    *Config
+	eventChan chan FileEvent
+	done      chan bool
+	interval  time.Duration
+	filter    *eventFilter
+	log       *Logger
+
+	snapshot map[string]fileSnapshot
+}
+
+// NewPollingFileWatcher creates a watcher that polls instead of subscribing
+// to filesystem notifications.
+func NewPollingFileWatcher(config *Config) (*PollingFileWatcher, error) {
+	filter, err := newEventFilter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := time.Duration(config.PollIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	return &PollingFileWatcher{
+		config:    config,
+		eventChan: make(chan FileEvent, 100),
+		done:      make(chan bool),
+		interval:  interval,
+		filter:    filter,
+		log:       logger.With("component", "watcher.poll"),
+		snapshot:  make(map[string]fileSnapshot),
+	}, nil
+}
+
+// Stats returns a snapshot of the watcher's event classification counters.
+func (pw *PollingFileWatcher) Stats() WatcherStats {
+	return pw.filter.Stats()
+}
+
+// Start begins polling the configured iCloud and outputs directories.
+func (pw *PollingFileWatcher) Start(ctx context.Context) error {
+	icloudPath, err := pw.config.getICloudPath()
+	if err != nil {
+		return err
+	}
+	roots := []string{icloudPath}
+
+	if outputPath, err := pw.config.getOutputPath(); err != nil {
+		pw.log.Warn("Could not get output path, reverse sync might not work", "error", err)
+	} else if _, err := os.Stat(outputPath); err == nil {
+		roots = append(roots, outputPath)
+	}
+
+	// Take the initial snapshot synchronously, so the first tick only
+	// reports changes made after Start rather than reporting every
+	// pre-existing file as a Create.
+	pw.snapshot = pw.scan(roots)
+
+	go pw.pollLoop(ctx, roots)
+	pw.log.Info("Polling file watcher started", "roots", roots, "interval", pw.interval)
+	return nil
+}
+
+func (pw *PollingFileWatcher) pollLoop(ctx context.Context, roots []string) {
+	ticker := time.NewTicker(pw.interval)
+	defer ticker.Stop()
+	defer close(pw.eventChan)
+
+	for {
+		select {
+		case <-ctx.Done():
+			pw.log.Info("Polling file watcher stopped")
+			return
+		case <-pw.done:
+			pw.log.Info("Polling file watcher shutdown requested")
+			return
+		case <-ticker.C:
+			pw.poll(roots)
+		}
+	}
+}
+
+// scan walks roots and records a fileSnapshot for every path that passes
+// the watcher's skip/allow rules.
+func (pw *PollingFileWatcher) scan(roots []string) map[string]fileSnapshot {
+	snapshot := make(map[string]fileSnapshot)
+
+	for _, root := range roots {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				pw.log.Warn("Error walking directory during poll", "path", path, "error", err)
+				return nil
+			}
+
+			if info.IsDir() {
+				if path != root && pw.filter.shouldSkipDirectory(path) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if pw.filter.shouldSkipFile(path) {
+				return nil
+			}
+
+			snapshot[path] = fileSnapshot{
+				size:    info.Size(),
+				modTime: info.ModTime(),
+				mode:    info.Mode(),
+				isDir:   false,
+			}
+			return nil
+		})
+		if err != nil {
+			pw.log.Warn("Error scanning root during poll", "root", root, "error", err)
+		}
+	}
+
+	return snapshot
+}
+
+// poll takes a fresh snapshot of roots, diffs it against the previous one,
+// and emits synthetic FileEvents for anything that changed.
+func (pw *PollingFileWatcher) poll(roots []string) {
+	current := pw.scan(roots)
+	previous := pw.snapshot
+	pw.snapshot = current
+
+	var created, removed []string
+	for path, snap := range current {
+		prev, existed := previous[path]
+		if !existed {
+			created = append(created, path)
+			continue
+		}
+		if prev.size != snap.size || !prev.modTime.Equal(snap.modTime) || prev.mode != snap.mode {
+			pw.emit(path, "WRITE", false)
+		}
+	}
+	for path := range previous {
+		if _, stillExists := current[path]; !stillExists {
+			removed = append(removed, path)
+		}
+	}
+
+	// Best-effort rename detection: a single file disappearing and a
+	// single same-size file appearing in the same tick is treated as a
+	// rename rather than a remove+create. Anything less unambiguous (no
+	// inode tracking is available from os.FileInfo alone) falls back to
+	// separate Create/Remove events.
+	if len(created) == 1 && len(removed) == 1 && current[created[0]].size == previous[removed[0]].size {
+		pw.emit(created[0], "RENAME", false)
+		return
+	}
+
+	for _, path := range created {
+		pw.emit(path, "CREATE", false)
+	}
+	for _, path := range removed {
+		pw.emit(path, "REMOVE", false)
+	}
+}
+
+func (pw *PollingFileWatcher) emit(path, op string, isDir bool) {
+	fileEvent := FileEvent{
+		Path:      path,
+		Operation: op,
+		Ops:       []string{op},
+		IsDir:     isDir,
+		Timestamp: time.Now(),
+	}
+
+	pw.log.Info("Polling file event detected", "path", path, "operation", op)
+
+	select {
+	case pw.eventChan <- fileEvent:
+	default:
+		pw.log.Warn("Event channel full, dropping event", "path", path)
+		pw.filter.recordDrop()
+	}
+}
+
+// GetEventChannel returns the channel for receiving file events.
+func (pw *PollingFileWatcher) GetEventChannel() <-chan FileEvent {
+	return pw.eventChan
+}
+
+// Stop stops the polling file watcher.
+func (pw *PollingFileWatcher) Stop() error {
+	pw.log.Info("Stopping polling file watcher")
+	close(pw.done)
+	return nil
+}
+
+var _ Watcher = (*PollingFileWatcher)(nil)
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ProviderEntry describes one object as reported by a Provider's List or
+// Stat.
+type ProviderEntry struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+	IsDir   bool
+}
+
+// ProviderMeta carries the handful of things Put needs beyond the bytes
+// themselves - mirroring what uploadFile currently sends as multipart
+// fields.
+type ProviderMeta struct {
+	ModTime time.Time
+}
+
+// Provider is a storage backend SyncManager can read from or write to.
+// LocalProvider and ICloudProvider wrap plain filesystem paths; S3Provider,
+// WebDAVProvider, and SFTPProvider let a destination live off the host
+// entirely, so matter files can be mirrored to off-site storage without
+// the custom TM server.
+type Provider interface {
+	List(ctx context.Context, prefix string) ([]ProviderEntry, error)
+	Stat(ctx context.Context, key string) (ProviderEntry, error)
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Put(ctx context.Context, key string, r io.Reader, meta ProviderMeta) error
+	Delete(ctx context.Context, key string) error
+}
+
+// ProviderConfig is one named entry of Config.Providers. Type selects
+// which fields apply; unused fields for a given type are ignored.
+type ProviderConfig struct {
+	Type string `json:"type"`
+
+	// local / icloud
+	RootPath string `json:"root_path,omitempty"`
+
+	// s3
+	Bucket          string `json:"bucket,omitempty"`
+	Prefix          string `json:"prefix,omitempty"`
+	Endpoint        string `json:"endpoint,omitempty"`
+	Region          string `json:"region,omitempty"`
+	AccessKeyID     string `json:"access_key_id,omitempty"`
+	SecretAccessKey string `json:"secret_access_key,omitempty"`
+	UsePathStyle    bool   `json:"use_path_style,omitempty"`
+
+	// webdav
+	URL      string `json:"url,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+
+	// sftp
+	Host           string `json:"host,omitempty"`
+	Port           int    `json:"port,omitempty"`
+	PrivateKeyPath string `json:"private_key_path,omitempty"`
+}
+
+// NewProvider builds the Provider named by cfg.Type.
+func NewProvider(cfg ProviderConfig) (Provider, error) {
+	switch cfg.Type {
+	case "local":
+		return newLocalProvider(cfg.RootPath)
+	case "icloud":
+		return newICloudProvider(cfg.RootPath)
+	case "s3":
+		return newS3Provider(cfg)
+	case "webdav":
+		return newWebDAVProvider(cfg)
+	case "sftp":
+		return newSFTPProvider(cfg)
+	default:
+		return nil, fmt.Errorf("unknown provider type: %s (must be local, icloud, s3, webdav, or sftp)", cfg.Type)
+	}
+}
+
+// buildProviders constructs every provider named in config.Providers,
+// keyed the same way. A provider that fails to construct is logged and
+// skipped rather than failing the whole sync manager - e.g. an s3
+// destination with a typo'd bucket shouldn't prevent the primary
+// iCloud<->server sync from starting.
+func buildProviders(config *Config, log *Logger) map[string]Provider {
+	providers := make(map[string]Provider, len(config.Providers))
+	for name, cfg := range config.Providers {
+		provider, err := NewProvider(cfg)
+		if err != nil {
+			log.Warn("Failed to construct provider, skipping", "name", name, "type", cfg.Type, "error", err)
+			continue
+		}
+		providers[name] = provider
+	}
+	return providers
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// localProvider implements Provider over a plain directory on the local
+// filesystem. Keys are slash-separated paths relative to root.
+type localProvider struct {
+	root string
+}
+
+func newLocalProvider(root string) (Provider, error) {
+	if root == "" {
+		return nil, fmt.Errorf("local provider requires root_path")
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create local provider root: %w", err)
+	}
+	return &localProvider{root: root}, nil
+}
+
+// newICloudProvider is a localProvider rooted at the macOS iCloud Drive
+// path; it exists as a distinct Provider type (rather than reusing "local"
+// directly in config) so a provider entry can say "icloud" without the
+// caller needing to know the underlying Library/Mobile Documents path.
+func newICloudProvider(root string) (Provider, error) {
+	if root == "" {
+		return nil, fmt.Errorf("icloud provider requires root_path (resolved iCloud Drive folder)")
+	}
+	return newLocalProvider(root)
+}
+
+func (p *localProvider) resolve(key string) string {
+	return filepath.Join(p.root, filepath.FromSlash(key))
+}
+
+func (p *localProvider) List(_ context.Context, prefix string) ([]ProviderEntry, error) {
+	base := p.resolve(prefix)
+
+	var entries []ProviderEntry
+	err := filepath.Walk(base, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(p.root, path)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, ProviderEntry{
+			Key:     filepath.ToSlash(rel),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+			IsDir:   false,
+		})
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to list %s: %w", prefix, err)
+	}
+	return entries, nil
+}
+
+func (p *localProvider) Stat(_ context.Context, key string) (ProviderEntry, error) {
+	info, err := os.Stat(p.resolve(key))
+	if err != nil {
+		return ProviderEntry{}, err
+	}
+	return ProviderEntry{Key: key, Size: info.Size(), ModTime: info.ModTime(), IsDir: info.IsDir()}, nil
+}
+
+func (p *localProvider) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	return os.Open(p.resolve(key))
+}
+
+func (p *localProvider) Put(_ context.Context, key string, r io.Reader, meta ProviderMeta) error {
+	dest := p.resolve(key)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("failed to create destination directory: %w", err)
+	}
+
+	f, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("failed to create destination file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return fmt.Errorf("failed to write destination file: %w", err)
+	}
+
+	if !meta.ModTime.IsZero() {
+		if err := os.Chtimes(dest, meta.ModTime, meta.ModTime); err != nil {
+			return fmt.Errorf("failed to set destination mtime: %w", err)
+		}
+	}
+	return nil
+}
+
+func (p *localProvider) Delete(_ context.Context, key string) error {
+	return os.Remove(p.resolve(key))
+}
+
+var _ Provider = (*localProvider)(nil)
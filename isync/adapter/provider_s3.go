@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// s3Provider implements Provider against S3 or an S3-compatible service
+// (MinIO, etc. via cfg.Endpoint + UsePathStyle).
+type s3Provider struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+func newS3Provider(cfg ProviderConfig) (Provider, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 provider requires bucket")
+	}
+
+	opts := []func(*config.LoadOptions) error{}
+	if cfg.Region != "" {
+		opts = append(opts, config.WithRegion(cfg.Region))
+	}
+	if cfg.AccessKeyID != "" {
+		opts = append(opts, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, "")))
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+		o.UsePathStyle = cfg.UsePathStyle
+	})
+
+	return &s3Provider{client: client, bucket: cfg.Bucket, prefix: strings.Trim(cfg.Prefix, "/")}, nil
+}
+
+func (p *s3Provider) key(key string) string {
+	if p.prefix == "" {
+		return key
+	}
+	return p.prefix + "/" + strings.TrimPrefix(key, "/")
+}
+
+func (p *s3Provider) List(ctx context.Context, prefix string) ([]ProviderEntry, error) {
+	var entries []ProviderEntry
+	paginator := s3.NewListObjectsV2Paginator(p.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(p.bucket),
+		Prefix: aws.String(p.key(prefix)),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("s3 list failed: %w", err)
+		}
+		for _, obj := range page.Contents {
+			entries = append(entries, ProviderEntry{
+				Key:     strings.TrimPrefix(aws.ToString(obj.Key), p.prefix+"/"),
+				Size:    aws.ToInt64(obj.Size),
+				ModTime: aws.ToTime(obj.LastModified),
+			})
+		}
+	}
+	return entries, nil
+}
+
+func (p *s3Provider) Stat(ctx context.Context, key string) (ProviderEntry, error) {
+	out, err := p.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(p.bucket),
+		Key:    aws.String(p.key(key)),
+	})
+	if err != nil {
+		return ProviderEntry{}, fmt.Errorf("s3 head object failed: %w", err)
+	}
+	return ProviderEntry{Key: key, Size: aws.ToInt64(out.ContentLength), ModTime: aws.ToTime(out.LastModified)}, nil
+}
+
+func (p *s3Provider) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := p.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(p.bucket),
+		Key:    aws.String(p.key(key)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("s3 get object failed: %w", err)
+	}
+	return out.Body, nil
+}
+
+func (p *s3Provider) Put(ctx context.Context, key string, r io.Reader, meta ProviderMeta) error {
+	_, err := p.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(p.bucket),
+		Key:    aws.String(p.key(key)),
+		Body:   r,
+	})
+	if err != nil {
+		return fmt.Errorf("s3 put object failed: %w", err)
+	}
+	return nil
+}
+
+func (p *s3Provider) Delete(ctx context.Context, key string) error {
+	_, err := p.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(p.bucket),
+		Key:    aws.String(p.key(key)),
+	})
+	if err != nil {
+		return fmt.Errorf("s3 delete object failed: %w", err)
+	}
+	return nil
+}
+
+var _ Provider = (*s3Provider)(nil)
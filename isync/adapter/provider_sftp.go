@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// sftpProvider implements Provider over an SFTP connection, authenticated
+// with a private key - the common case for a firm's own NAS or a VPS used
+// as an off-site backup target when S3/WebDAV aren't available.
+type sftpProvider struct {
+	client *sftp.Client
+	conn   *ssh.Client
+	root   string
+}
+
+func newSFTPProvider(cfg ProviderConfig) (Provider, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("sftp provider requires host")
+	}
+	if cfg.PrivateKeyPath == "" {
+		return nil, fmt.Errorf("sftp provider requires private_key_path")
+	}
+
+	keyData, err := os.ReadFile(cfg.PrivateKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sftp private key: %w", err)
+	}
+	signer, err := ssh.ParsePrivateKey(keyData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse sftp private key: %w", err)
+	}
+
+	port := cfg.Port
+	if port == 0 {
+		port = 22
+	}
+
+	sshConfig := &ssh.ClientConfig{
+		User:            cfg.Username,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         10 * time.Second,
+	}
+
+	conn, err := ssh.Dial("tcp", fmt.Sprintf("%s:%d", cfg.Host, port), sshConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial sftp host: %w", err)
+	}
+
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to start sftp session: %w", err)
+	}
+
+	root := cfg.RootPath
+	if root == "" {
+		root = "."
+	}
+
+	return &sftpProvider{client: client, conn: conn, root: root}, nil
+}
+
+func (p *sftpProvider) resolve(key string) string {
+	return path.Join(p.root, key)
+}
+
+func (p *sftpProvider) List(_ context.Context, prefix string) ([]ProviderEntry, error) {
+	walker := p.client.Walk(p.resolve(prefix))
+
+	var entries []ProviderEntry
+	for walker.Step() {
+		if walker.Err() != nil {
+			continue
+		}
+		info := walker.Stat()
+		if info.IsDir() {
+			continue
+		}
+		entries = append(entries, ProviderEntry{Key: walker.Path(), Size: info.Size(), ModTime: info.ModTime()})
+	}
+	return entries, nil
+}
+
+func (p *sftpProvider) Stat(_ context.Context, key string) (ProviderEntry, error) {
+	info, err := p.client.Stat(p.resolve(key))
+	if err != nil {
+		return ProviderEntry{}, fmt.Errorf("sftp stat failed: %w", err)
+	}
+	return ProviderEntry{Key: key, Size: info.Size(), ModTime: info.ModTime(), IsDir: info.IsDir()}, nil
+}
+
+func (p *sftpProvider) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	f, err := p.client.Open(p.resolve(key))
+	if err != nil {
+		return nil, fmt.Errorf("sftp open failed: %w", err)
+	}
+	return f, nil
+}
+
+func (p *sftpProvider) Put(_ context.Context, key string, r io.Reader, meta ProviderMeta) error {
+	dest := p.resolve(key)
+	if err := p.client.MkdirAll(path.Dir(dest)); err != nil {
+		return fmt.Errorf("sftp mkdir failed: %w", err)
+	}
+
+	f, err := p.client.Create(dest)
+	if err != nil {
+		return fmt.Errorf("sftp create failed: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return fmt.Errorf("sftp write failed: %w", err)
+	}
+	return nil
+}
+
+func (p *sftpProvider) Delete(_ context.Context, key string) error {
+	if err := p.client.Remove(p.resolve(key)); err != nil {
+		return fmt.Errorf("sftp remove failed: %w", err)
+	}
+	return nil
+}
+
+// Close releases the underlying SFTP/SSH connection. Not part of the
+// Provider interface (most providers are connectionless), so callers that
+// care should type-assert for it.
+func (p *sftpProvider) Close() error {
+	p.client.Close()
+	return p.conn.Close()
+}
+
+var _ Provider = (*sftpProvider)(nil)
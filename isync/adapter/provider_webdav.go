@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// webdavProvider implements Provider against a WebDAV server using plain
+// HTTP methods (GET/PUT/DELETE/PROPFIND/MKCOL) - WebDAV doesn't need a
+// client SDK, just http.Client and a PROPFIND response parser.
+type webdavProvider struct {
+	baseURL  string
+	username string
+	password string
+	client   *http.Client
+}
+
+func newWebDAVProvider(cfg ProviderConfig) (Provider, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("webdav provider requires url")
+	}
+	return &webdavProvider{
+		baseURL:  strings.TrimSuffix(cfg.URL, "/"),
+		username: cfg.Username,
+		password: cfg.Password,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (p *webdavProvider) resolve(key string) string {
+	return p.baseURL + "/" + strings.TrimPrefix(url.PathEscape(key), "/")
+}
+
+func (p *webdavProvider) do(ctx context.Context, method, key string, body io.Reader, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, p.resolve(key), body)
+	if err != nil {
+		return nil, err
+	}
+	if p.username != "" {
+		req.SetBasicAuth(p.username, p.password)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return p.client.Do(req)
+}
+
+// davMultistatus is the minimal subset of a WebDAV PROPFIND response this
+// provider needs: each member's path, size, and last-modified time.
+type davMultistatus struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		PropStat struct {
+			Prop struct {
+				ContentLength string `xml:"getcontentlength"`
+				LastModified  string `xml:"getlastmodified"`
+				ResourceType  struct {
+					Collection *struct{} `xml:"collection"`
+				} `xml:"resourcetype"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+func (p *webdavProvider) List(ctx context.Context, prefix string) ([]ProviderEntry, error) {
+	resp, err := p.do(ctx, "PROPFIND", prefix, nil, map[string]string{"Depth": "1"})
+	if err != nil {
+		return nil, fmt.Errorf("webdav PROPFIND failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 207 && resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("webdav PROPFIND returned status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PROPFIND response: %w", err)
+	}
+
+	var ms davMultistatus
+	if err := xml.Unmarshal(data, &ms); err != nil {
+		return nil, fmt.Errorf("failed to parse PROPFIND response: %w", err)
+	}
+
+	var entries []ProviderEntry
+	for _, r := range ms.Responses {
+		if r.PropStat.Prop.ResourceType.Collection != nil {
+			continue
+		}
+		size, _ := strconv.ParseInt(r.PropStat.Prop.ContentLength, 10, 64)
+		modTime, _ := time.Parse(time.RFC1123, r.PropStat.Prop.LastModified)
+		entries = append(entries, ProviderEntry{
+			Key:     strings.TrimPrefix(r.Href, "/"),
+			Size:    size,
+			ModTime: modTime,
+		})
+	}
+	return entries, nil
+}
+
+func (p *webdavProvider) Stat(ctx context.Context, key string) (ProviderEntry, error) {
+	resp, err := p.do(ctx, "HEAD", key, nil, nil)
+	if err != nil {
+		return ProviderEntry{}, fmt.Errorf("webdav HEAD failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ProviderEntry{}, fmt.Errorf("webdav: %s not found", key)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return ProviderEntry{}, fmt.Errorf("webdav HEAD returned status %d", resp.StatusCode)
+	}
+
+	modTime, _ := time.Parse(time.RFC1123, resp.Header.Get("Last-Modified"))
+	return ProviderEntry{Key: key, Size: resp.ContentLength, ModTime: modTime}, nil
+}
+
+func (p *webdavProvider) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := p.do(ctx, "GET", key, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("webdav GET failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("webdav GET returned status %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+func (p *webdavProvider) Put(ctx context.Context, key string, r io.Reader, meta ProviderMeta) error {
+	resp, err := p.do(ctx, "PUT", key, r, map[string]string{"Content-Type": "application/octet-stream"})
+	if err != nil {
+		return fmt.Errorf("webdav PUT failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("webdav PUT returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (p *webdavProvider) Delete(ctx context.Context, key string) error {
+	resp, err := p.do(ctx, "DELETE", key, nil, nil)
+	if err != nil {
+		return fmt.Errorf("webdav DELETE failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("webdav DELETE returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+var _ Provider = (*webdavProvider)(nil)
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// reconcileSnapshot persists the (path, size, mtime) state seen at the end
+// of the last run, so startup can tell what changed while the daemon (and
+// its watcher) was down.
+func reconcileSnapshotPath(journalPath string) string {
+	return journalPath + ".snapshot"
+}
+
+func loadReconcileSnapshot(path string) (map[string]fileSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]fileSnapshot{}, nil
+		}
+		return nil, err
+	}
+
+	var snapshot map[string]fileSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+func saveReconcileSnapshot(path string, snapshot map[string]fileSnapshot) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// reconcileScan walks roots, diffs the result against the previous
+// snapshot, and returns synthetic FileEvents for anything that changed -
+// created, written, or removed - while nothing was watching. It reuses the
+// same fileSnapshot/filter logic PollingFileWatcher uses for its own
+// periodic diffing.
+func reconcileScan(roots []string, previous map[string]fileSnapshot, filter *eventFilter) ([]FileEvent, map[string]fileSnapshot) {
+	current := make(map[string]fileSnapshot)
+
+	for _, root := range roots {
+		filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if path != root && filter.shouldSkipDirectory(path) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if filter.shouldSkipFile(path) {
+				return nil
+			}
+			current[path] = fileSnapshot{
+				size:    info.Size(),
+				modTime: info.ModTime(),
+				mode:    info.Mode(),
+				isDir:   false,
+			}
+			return nil
+		})
+	}
+
+	var events []FileEvent
+	for path, snap := range current {
+		prev, existed := previous[path]
+		if !existed {
+			events = append(events, reconcileEvent(path, "CREATE"))
+			continue
+		}
+		if prev.size != snap.size || !prev.modTime.Equal(snap.modTime) {
+			events = append(events, reconcileEvent(path, "WRITE"))
+		}
+	}
+	for path := range previous {
+		if _, stillExists := current[path]; !stillExists {
+			events = append(events, reconcileEvent(path, "REMOVE"))
+		}
+	}
+
+	return events, current
+}
+
+func reconcileEvent(path, op string) FileEvent {
+	return FileEvent{
+		Path:      path,
+		Operation: op,
+		Ops:       []string{op},
+		IsDir:     false,
+		Timestamp: time.Now(),
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/thejerf/suture/v4"
+)
+
+// Exit codes mirroring Syncthing's convention, so a systemd/launchd unit
+// watching this process's exit status can tell a plain failure apart from
+// "please restart me" and "please re-exec the binary I just swapped in".
+const (
+	exitSuccess    = 0
+	exitError      = 1
+	exitRestarting = 3
+	exitUpgrading  = 4
+)
+
+// defaultRestartBackoff, defaultMaxRestarts and defaultRestartWindow are
+// used when the corresponding Config fields are unset.
+const (
+	defaultRestartBackoff = 5 * time.Second
+	defaultMaxRestarts    = 5
+	defaultRestartWindow  = 30 * time.Second
+)
+
+// syncManagerService adapts SyncManager to suture's Service interface so it
+// can be supervised and restarted independently of statusReporterService
+// and adminServerService.
+type syncManagerService struct {
+	app *Application
+}
+
+func (s syncManagerService) Serve(ctx context.Context) error {
+	if err := s.app.syncManager.Start(ctx); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	return s.app.syncManager.Stop()
+}
+
+func (s syncManagerService) String() string { return "sync-manager" }
+
+// statusReporterService periodically logs SyncStats until ctx is cancelled.
+type statusReporterService struct {
+	app *Application
+}
+
+func (s statusReporterService) Serve(ctx context.Context) error {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			stats := s.app.syncManager.GetStats()
+			logger.Info("Sync status",
+				"files_uploaded", stats.FilesUploaded,
+				"files_downloaded", stats.FilesDownloaded,
+				"directories_synced", stats.DirectoriesSync,
+				"errors", stats.Errors,
+				"last_sync", stats.LastSync.Format("2006-01-02 15:04:05"),
+				"uptime", time.Since(stats.StartTime).String())
+		}
+	}
+}
+
+func (s statusReporterService) String() string { return "status-reporter" }
+
+// adminServerService wraps AdminServer's start-then-wait lifecycle as a
+// suture Service. It's only registered with the supervisor when AdminAddr
+// is configured.
+type adminServerService struct {
+	app *Application
+}
+
+func (s adminServerService) Serve(ctx context.Context) error {
+	admin := NewAdminServer(s.app.config.AdminAddr, s.app)
+	if err := admin.Start(); err != nil {
+		return err
+	}
+	s.app.adminServer = admin
+
+	<-ctx.Done()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return admin.Stop(shutdownCtx)
+}
+
+func (s adminServerService) String() string { return "admin-server" }
+
+// newSupervisor builds the suture.Supervisor tree for app: the sync
+// manager, the periodic status reporter, and (if configured) the admin
+// server. Each is restarted independently on panic or return, rather than
+// taking the whole binary down with it - mirrors the supervisor tree
+// Syncthing builds on thejerf/suture. Once the failure count (decaying
+// with a half-life of the restart window) exceeds maxRestarts, restarts
+// pause for the fixed backoff and then resume; the backoff does not grow,
+// and repeated failures never stop the supervisor.
+func newSupervisor(app *Application) *suture.Supervisor {
+	backoff := time.Duration(app.config.RestartBackoffSeconds) * time.Second
+	if backoff <= 0 {
+		backoff = defaultRestartBackoff
+	}
+	maxRestarts := app.config.MaxRestarts
+	if maxRestarts <= 0 {
+		maxRestarts = defaultMaxRestarts
+	}
+	restartWindow := time.Duration(app.config.RestartWindowSeconds) * time.Second
+	if restartWindow <= 0 {
+		restartWindow = defaultRestartWindow
+	}
+
+	sup := suture.New("tm-isync", suture.Spec{
+		FailureDecay:     restartWindow.Seconds(),
+		FailureThreshold: float64(maxRestarts),
+		FailureBackoff:   backoff,
+	})
+
+	sup.Add(syncManagerService{app: app})
+	sup.Add(statusReporterService{app: app})
+	if app.config.AdminAddr != "" {
+		sup.Add(adminServerService{app: app})
+	}
+
+	return sup
+}
@@ -1,24 +1,60 @@
 package main
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// errLockBusy is returned by withLock when the run lock is held by
+// another process and still healthy when the configured timeout elapses.
+var errLockBusy = errors.New("run lock busy")
+
 // SyncManager handles bidirectional file synchronization
 type SyncManager struct {
-	config    *Config
-	watcher   *FileWatcher
-	syncing   bool
+	config  *Config
+	watcher Watcher
+
+	// syncingFlag is set while a full performInitialSync/periodicSync pass
+	// is running, so a concurrent file event doesn't race a walk already
+	// in flight. pathLocks additionally serializes events that land on the
+	// same relative path without blocking events on unrelated ones.
+	syncingFlag atomic.Bool
+	pathLocks   keyedMutex
+
 	syncStats SyncStats
+	log       *Logger
+
+	journal *EventJournal
+
+	journalStatsMu sync.Mutex
+	journalStats   WatcherStats
+
+	manifestsMu sync.Mutex
+	manifests   *ManifestStore
+
+	blobStoreMu sync.Mutex
+	blobStore   *BlobStore
+
+	lockerMu sync.Mutex
+	locker   *Locker
+
+	syncStatsMu sync.Mutex
+
+	uploadPool *workerPool
+	copyPool   *workerPool
+
+	providers map[string]Provider
+
+	audit *AuditLogger
 }
 
 // SyncStats tracks synchronization statistics
@@ -29,33 +65,132 @@ type SyncStats struct {
 	Errors          int
 	LastSync        time.Time
 	StartTime       time.Time
+
+	// InFlight and Queued reflect the upload/copy worker pools' current
+	// load (summed across both), refreshed by GetStats.
+	InFlight int
+	Queued   int
+}
+
+// recordSyncStat updates the SyncStats counters under lock - required
+// once uploadICloudFiles/syncTMToICloud fan work out across a workerPool
+// instead of updating them inline on a single walking goroutine.
+func (sm *SyncManager) recordSyncStat(update func(*SyncStats)) {
+	sm.syncStatsMu.Lock()
+	defer sm.syncStatsMu.Unlock()
+	update(&sm.syncStats)
 }
 
 // NewSyncManager creates a new sync manager
 func NewSyncManager(config *Config) (*SyncManager, error) {
-	watcher, err := NewFileWatcher(config)
+	watcher, err := NewWatcher(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create file watcher: %w", err)
 	}
 
+	journalPath, err := config.getJournalPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve journal path: %w", err)
+	}
+	journal, err := NewEventJournal(journalPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event journal: %w", err)
+	}
+
+	syncLog := logger.With("component", "sync.icloud")
+
+	maxUploads := config.MaxConcurrentUploads
+	if maxUploads <= 0 {
+		maxUploads = 5
+	}
+	maxCopies := config.MaxConcurrentCopies
+	if maxCopies <= 0 {
+		maxCopies = 10
+	}
+
+	audit, err := NewAuditLogger(config.AuditLog)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
 	return &SyncManager{
 		config:  config,
 		watcher: watcher,
-		syncing: false,
 		syncStats: SyncStats{
 			StartTime: time.Now(),
 		},
+		log:        syncLog,
+		journal:    journal,
+		uploadPool: newWorkerPool(maxUploads, maxUploads*4),
+		copyPool:   newWorkerPool(maxCopies, maxCopies*4),
+		providers:  buildProviders(config, syncLog),
+		audit:      audit,
 	}, nil
 }
 
+// mirrorToDestinations copies filePath to every provider named in
+// Config.SyncDestinations, logging (not failing) on a per-destination
+// error so one misconfigured backup target doesn't block the primary sync.
+func (sm *SyncManager) mirrorToDestinations(ctx context.Context, filePath, relPath string) {
+	if len(sm.config.SyncDestinations) == 0 {
+		return
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		sm.log.Warn("Could not stat file for destination mirroring", "path", filePath, "error", err)
+		return
+	}
+
+	for _, name := range sm.config.SyncDestinations {
+		provider, ok := sm.providers[name]
+		if !ok {
+			sm.log.Warn("Sync destination not found in providers", "name", name)
+			continue
+		}
+
+		f, err := os.Open(filePath)
+		if err != nil {
+			sm.log.Warn("Could not open file for destination mirroring", "destination", name, "path", filePath, "error", err)
+			continue
+		}
+
+		err = provider.Put(ctx, relPath, f, ProviderMeta{ModTime: info.ModTime()})
+		f.Close()
+		if err != nil {
+			sm.log.Warn("Failed to mirror file to destination", "destination", name, "path", relPath, "error", err)
+			continue
+		}
+		sm.log.Debug("Mirrored file to destination", "destination", name, "path", relPath)
+	}
+}
+
 // Start begins the sync process
 func (sm *SyncManager) Start(ctx context.Context) error {
-	logger.Info("Starting sync manager")
+	sm.log.Info("Starting sync manager")
+
+	// Attach a SyncContext so handlers further down the call chain can
+	// recover this run's config/logger/providers from ctx alone, rather
+	// than always reaching back through sm. This is what lets more than
+	// one SyncManager run in a process without their logging bleeding
+	// into each other.
+	ctx = WithSync(ctx, &SyncContext{
+		Config:     sm.config,
+		Log:        sm.log,
+		HTTPClient: &http.Client{Timeout: time.Second * 30},
+		Providers:  sm.providers,
+	})
+
+	// Replay anything journaled but never acked (e.g. the daemon crashed
+	// mid-sync) and reconcile against what changed on disk while nothing
+	// was watching, before the watcher starts producing new events.
+	sm.replayUnacked(ctx)
+	sm.reconcile(ctx)
 
 	// Perform initial sync
 	err := sm.performInitialSync()
 	if err != nil {
-		logger.Error("Initial sync failed", "error", err)
+		sm.log.Error("Initial sync failed", "error", err)
 		// Don't return error - continue with event-based sync
 	}
 
@@ -71,46 +206,107 @@ func (sm *SyncManager) Start(ctx context.Context) error {
 	// Start periodic sync
 	go sm.periodicSync(ctx)
 
-	logger.Info("Sync manager started successfully")
+	// Start the blob retention pruner (no-op unless RetentionDays is set)
+	go sm.blobPruner(ctx)
+
+	sm.log.Info("Sync manager started successfully")
 	return nil
 }
 
-// performInitialSync performs a full bidirectional sync on startup
+// lockerFor lazily creates (or reuses) the Locker for root, caching it on
+// the SyncManager since it's acquired before every sync run.
+func (sm *SyncManager) lockerFor(root string) *Locker {
+	sm.lockerMu.Lock()
+	defer sm.lockerMu.Unlock()
+
+	if sm.locker == nil {
+		sm.locker = NewLocker(root, time.Duration(sm.config.StaleLockSeconds)*time.Second)
+	}
+	return sm.locker
+}
+
+// withLock acquires the cross-process run lock, guarding against two
+// daemons pointed at the same iCloud folder (e.g. one per host) racing
+// the same sync work, runs action while holding it, and releases it
+// afterward. It returns errLockBusy (not an error worth retrying) if the
+// lock is still held by someone else once Config.LockTimeoutSeconds
+// elapses - the caller should skip this run, not queue it.
+func (sm *SyncManager) withLock(action func() error) error {
+	root, err := sm.config.getICloudPath()
+	if err != nil {
+		return err
+	}
+
+	timeout := time.Duration(sm.config.LockTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultLockTimeout
+	}
+
+	locker := sm.lockerFor(root)
+	acquired, err := locker.Acquire(timeout)
+	if err != nil {
+		return fmt.Errorf("failed to acquire run lock: %w", err)
+	}
+	if !acquired {
+		return errLockBusy
+	}
+	defer locker.Release()
+
+	return action()
+}
+
+// performInitialSync performs a full bidirectional sync on startup, under
+// the cross-process run lock so a second daemon on another host pointed
+// at the same iCloud folder doesn't race it.
 func (sm *SyncManager) performInitialSync() error {
-	logger.Info("Performing initial sync...")
+	err := sm.withLock(sm.performInitialSyncLocked)
+	if errors.Is(err, errLockBusy) {
+		sm.log.Warn("Skipping initial sync: run lock held by another process", "timeout_seconds", sm.config.LockTimeoutSeconds)
+		return nil
+	}
+	return err
+}
+
+func (sm *SyncManager) performInitialSyncLocked() error {
+	sm.log.Info("Performing initial sync...")
 
 	// Sync from iCloud to Server
 	err := sm.uploadICloudFiles()
 	if err != nil {
-		logger.Error("iCloud to Server sync failed", "error", err)
+		sm.log.Error("iCloud to Server sync failed", "error", err)
 		return err
 	}
 
 	// Sync from TM to iCloud (for processed files)
 	err = sm.syncTMToICloud()
 	if err != nil {
-		logger.Error("TM to iCloud sync failed", "error", err)
+		sm.log.Error("TM to iCloud sync failed", "error", err)
 		return err
 	}
 
-	sm.syncStats.LastSync = time.Now()
-	logger.Info("Initial sync completed", "files_uploaded", sm.syncStats.FilesUploaded, "files_downloaded", sm.syncStats.FilesDownloaded, "dirs_synced", sm.syncStats.DirectoriesSync)
+	sm.recordSyncStat(func(s *SyncStats) { s.LastSync = time.Now() })
+	stats := sm.GetStats()
+	sm.log.Info("Initial sync completed", "files_uploaded", stats.FilesUploaded, "files_downloaded", stats.FilesDownloaded, "dirs_synced", stats.DirectoriesSync)
 	return nil
 }
 
-// uploadICloudFiles syncs files from iCloud Drive to the remote server
+// uploadICloudFiles syncs files from iCloud Drive to the remote server.
+// Each file is fanned out onto sm.uploadPool (bounded by
+// Config.MaxConcurrentUploads) rather than uploaded one at a time on the
+// walking goroutine; per-path work still only ever runs on one worker at
+// a time via sm.pathLocks.
 func (sm *SyncManager) uploadICloudFiles() error {
 	icloudPath, err := sm.config.getICloudPath()
 	if err != nil {
 		return fmt.Errorf("failed to get iCloud path: %w", err)
 	}
 
-	logger.Info("Checking for files to upload from iCloud...", "source", icloudPath)
+	sm.log.Info("Checking for files to upload from iCloud...", "source", icloudPath)
 
-	return filepath.Walk(icloudPath, func(srcPath string, info os.FileInfo, err error) error {
+	walkErr := filepath.Walk(icloudPath, func(srcPath string, info os.FileInfo, err error) error {
 		if err != nil {
-			logger.Warn("Error walking iCloud directory", "path", srcPath, "error", err)
-			sm.syncStats.Errors++
+			sm.log.Warn("Error walking iCloud directory", "path", srcPath, "error", err)
+			sm.recordSyncStat(func(s *SyncStats) { s.Errors++ })
 			return nil // Continue walking
 		}
 
@@ -121,76 +317,89 @@ func (sm *SyncManager) uploadICloudFiles() error {
 		// Calculate relative path
 		relPath, err := filepath.Rel(icloudPath, srcPath)
 		if err != nil {
-			logger.Warn("Failed to calculate relative path", "path", srcPath, "error", err)
-			sm.syncStats.Errors++
+			sm.log.Warn("Failed to calculate relative path", "path", srcPath, "error", err)
+			sm.recordSyncStat(func(s *SyncStats) { s.Errors++ })
 			return nil
 		}
 
-		err = sm.uploadFile(srcPath, relPath)
-		if err != nil {
-			logger.Warn("Failed to upload file", "path", srcPath, "error", err)
-			sm.syncStats.Errors++
-		} else {
-			sm.syncStats.FilesUploaded++
-			logger.Info("Successfully uploaded file", "file", relPath)
-		}
+		sm.uploadPool.Submit(func() {
+			release := sm.pathLocks.Lock(relPath)
+			defer release()
+
+			start := time.Now()
+			if err := sm.uploadFileDelta(icloudPath, srcPath, relPath); err != nil {
+				sm.log.Warn("Failed to upload file", "path", srcPath, "error", err)
+				sm.recordSyncStat(func(s *SyncStats) { s.Errors++ })
+				sm.audit.Record(AuditEvent{Type: AuditError, Path: relPath, Direction: "icloud_to_server", DurationMs: time.Since(start).Milliseconds(), Error: err.Error()})
+				return
+			}
+			sm.recordSyncStat(func(s *SyncStats) { s.FilesUploaded++ })
+			sm.audit.Record(AuditEvent{Type: AuditUpload, Path: relPath, Size: info.Size(), Direction: "icloud_to_server", DurationMs: time.Since(start).Milliseconds()})
+			sm.log.Info("Successfully uploaded file", "file", relPath)
+			sm.mirrorToDestinations(context.Background(), srcPath, relPath)
+		})
 
 		return nil
 	})
+
+	sm.uploadPool.Wait()
+	return walkErr
 }
 
-// uploadFile uploads a single file to the remote server
+// uploadFile uploads a single file as a content-addressed blob (see
+// BlobStore): it hashes filePath, skips sending the bytes entirely if the
+// server already has that hash, and in either case binds relativePath (at
+// a new version number) to the hash via a ref call, then archives the
+// content locally under the date-partitioned blob store for retention and
+// point-in-time restore.
 func (sm *SyncManager) uploadFile(filePath, relativePath string) error {
-	logger.Info("Uploading file...", "file", relativePath)
+	sm.log.Info("Uploading file...", "file", relativePath)
 
-	file, err := os.Open(filePath)
+	hash, size, err := hashFile(filePath)
 	if err != nil {
-		return fmt.Errorf("could not open file: %w", err)
+		return fmt.Errorf("could not hash file: %w", err)
 	}
-	defer file.Close()
-
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
 
-	part, err := writer.CreateFormFile("file", filepath.Base(filePath))
+	info, err := os.Stat(filePath)
 	if err != nil {
-		return fmt.Errorf("could not create form file: %w", err)
+		return fmt.Errorf("could not stat file: %w", err)
 	}
 
-	_, err = io.Copy(part, file)
+	exists, err := sm.blobExists(hash)
 	if err != nil {
-		return fmt.Errorf("could not copy file to buffer: %w", err)
+		sm.log.Warn("Blob existence check failed, uploading anyway", "path", relativePath, "error", err)
+		exists = false
 	}
 
-	// Add relative path so the server knows where to save it
-	_ = writer.WriteField("relative_path", relativePath)
+	if exists {
+		sm.log.Debug("Blob already present on server, skipping upload", "path", relativePath, "sha256", hash)
+	} else if err := sm.uploadBlob(filePath, hash, size); err != nil {
+		return fmt.Errorf("failed to upload blob: %w", err)
+	}
 
-	err = writer.Close()
+	root, err := sm.config.getICloudPath()
 	if err != nil {
-		return fmt.Errorf("could not close multipart writer: %w", err)
+		return fmt.Errorf("failed to get iCloud path: %w", err)
 	}
-
-	req, err := http.NewRequest("POST", sm.config.ApiEndpoint, body)
+	store, err := sm.blobStoreFor(root)
 	if err != nil {
-		return fmt.Errorf("could not create request: %w", err)
+		return fmt.Errorf("failed to open blob store: %w", err)
 	}
 
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-	req.Header.Set("Authorization", "Bearer "+sm.config.ApiKey)
-
-	client := &http.Client{Timeout: time.Second * 30}
-	resp, err := client.Do(req)
+	version, err := store.nextVersion(relativePath)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		sm.log.Warn("Failed to persist blob version, continuing with in-memory version", "path", relativePath, "error", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("upload failed with status %d: %s", resp.StatusCode, string(respBody))
+	if err := sm.createRef(relativePath, hash, version, info.ModTime()); err != nil {
+		return fmt.Errorf("failed to bind ref: %w", err)
 	}
 
-	logger.Info("File uploaded successfully", "file", relativePath)
+	if err := store.Archive(filePath, relativePath, hash, version, info.ModTime(), currentUploaderID()); err != nil {
+		sm.log.Warn("Failed to archive blob locally", "path", relativePath, "error", err)
+	}
+
+	sm.log.Info("File uploaded successfully", "file", relativePath, "sha256", hash, "version", version)
 	return nil
 }
 
@@ -203,7 +412,7 @@ func (sm *SyncManager) syncTMToICloud() error {
 
 	// Check if outputs directory exists
 	if _, err := os.Stat(outputPath); os.IsNotExist(err) {
-		logger.Info("TM outputs directory does not exist, skipping reverse sync", "path", outputPath)
+		sm.log.Info("TM outputs directory does not exist, skipping reverse sync", "path", outputPath)
 		return nil
 	}
 
@@ -219,12 +428,12 @@ func (sm *SyncManager) syncTMToICloud() error {
 		return fmt.Errorf("failed to create iCloud outputs directory: %w", err)
 	}
 
-	logger.Info("Syncing from TM to iCloud", "source", outputPath, "dest", icloudOutputPath)
+	sm.log.Info("Syncing from TM to iCloud", "source", outputPath, "dest", icloudOutputPath)
 
-	return filepath.Walk(outputPath, func(srcPath string, info os.FileInfo, err error) error {
+	walkErr := filepath.Walk(outputPath, func(srcPath string, info os.FileInfo, err error) error {
 		if err != nil {
-			logger.Warn("Error walking outputs directory", "path", srcPath, "error", err)
-			sm.syncStats.Errors++
+			sm.log.Warn("Error walking outputs directory", "path", srcPath, "error", err)
+			sm.recordSyncStat(func(s *SyncStats) { s.Errors++ })
 			return nil // Continue walking
 		}
 
@@ -236,35 +445,48 @@ func (sm *SyncManager) syncTMToICloud() error {
 		// Calculate relative path
 		relPath, err := filepath.Rel(outputPath, srcPath)
 		if err != nil {
-			logger.Warn("Failed to calculate relative path", "path", srcPath, "error", err)
-			sm.syncStats.Errors++
+			sm.log.Warn("Failed to calculate relative path", "path", srcPath, "error", err)
+			sm.recordSyncStat(func(s *SyncStats) { s.Errors++ })
 			return nil
 		}
 
 		// Calculate destination path
 		destPath := filepath.Join(icloudOutputPath, relPath)
-
-		// Sync file or directory
-		if info.IsDir() {
-			err = sm.syncDirectory(srcPath, destPath)
-			if err != nil {
-				logger.Warn("Failed to sync directory", "src", srcPath, "dest", destPath, "error", err)
-				sm.syncStats.Errors++
-			} else {
-				sm.syncStats.DirectoriesSync++
+		isDir := info.IsDir()
+
+		// Fan the actual copy work out onto sm.copyPool (bounded by
+		// Config.MaxConcurrentCopies); pathLocks keeps per-file work
+		// ordered even though many relative paths proceed concurrently.
+		sm.copyPool.Submit(func() {
+			release := sm.pathLocks.Lock(relPath)
+			defer release()
+
+			if isDir {
+				if err := sm.syncDirectory(srcPath, destPath); err != nil {
+					sm.log.Warn("Failed to sync directory", "src", srcPath, "dest", destPath, "error", err)
+					sm.recordSyncStat(func(s *SyncStats) { s.Errors++ })
+				} else {
+					sm.recordSyncStat(func(s *SyncStats) { s.DirectoriesSync++ })
+				}
+				return
 			}
-		} else {
-			err = sm.syncFile(srcPath, destPath)
-			if err != nil {
-				logger.Warn("Failed to sync file", "src", srcPath, "dest", destPath, "error", err)
-				sm.syncStats.Errors++
+
+			start := time.Now()
+			if err := sm.syncFile(srcPath, destPath); err != nil {
+				sm.log.Warn("Failed to sync file", "src", srcPath, "dest", destPath, "error", err)
+				sm.recordSyncStat(func(s *SyncStats) { s.Errors++ })
+				sm.audit.Record(AuditEvent{Type: AuditError, Path: relPath, Direction: "outputs_to_icloud", DurationMs: time.Since(start).Milliseconds(), Error: err.Error()})
 			} else {
-				sm.syncStats.FilesDownloaded++
+				sm.recordSyncStat(func(s *SyncStats) { s.FilesDownloaded++ })
+				sm.audit.Record(AuditEvent{Type: AuditDownload, Path: relPath, Size: info.Size(), Direction: "outputs_to_icloud", DurationMs: time.Since(start).Milliseconds()})
 			}
-		}
+		})
 
 		return nil
 	})
+
+	sm.copyPool.Wait()
+	return walkErr
 }
 
 // syncFile syncs a single file, handling conflicts with newer-file-wins policy
@@ -286,21 +508,21 @@ func (sm *SyncManager) syncFile(srcPath, destPath string) error {
 
 	// Both files exist, check which is newer
 	if srcInfo.ModTime().After(destInfo.ModTime()) {
-		logger.Debug("Source file is newer, copying", "src", srcPath, "dest", destPath)
+		sm.log.Debug("Source file is newer, copying", "src", srcPath, "dest", destPath)
 		return sm.copyFile(srcPath, destPath)
 	} else if destInfo.ModTime().After(srcInfo.ModTime()) {
-		logger.Debug("Destination file is newer, skipping", "src", srcPath, "dest", destPath)
+		sm.log.Debug("Destination file is newer, skipping", "src", srcPath, "dest", destPath)
 		return nil
 	}
 
 	// Files have same modification time, check size
 	if srcInfo.Size() != destInfo.Size() {
-		logger.Debug("Files have different sizes, copying", "src", srcPath, "dest", destPath)
+		sm.log.Debug("Files have different sizes, copying", "src", srcPath, "dest", destPath)
 		return sm.copyFile(srcPath, destPath)
 	}
 
 	// Files appear to be the same, skip
-	logger.Debug("Files appear identical, skipping", "src", srcPath, "dest", destPath)
+	sm.log.Debug("Files appear identical, skipping", "src", srcPath, "dest", destPath)
 	return nil
 }
 
@@ -311,7 +533,7 @@ func (sm *SyncManager) syncDirectory(srcPath, destPath string) error {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	logger.Debug("Directory synced", "src", srcPath, "dest", destPath)
+	sm.log.Debug("Directory synced", "src", srcPath, "dest", destPath)
 	return nil
 }
 
@@ -352,10 +574,10 @@ func (sm *SyncManager) copyFile(srcPath, destPath string) error {
 
 	err = os.Chtimes(destPath, srcInfo.ModTime(), srcInfo.ModTime())
 	if err != nil {
-		logger.Warn("Failed to set file modification time", "path", destPath, "error", err)
+		sm.log.Warn("Failed to set file modification time", "path", destPath, "error", err)
 	}
 
-	logger.Debug("File copied successfully", "src", srcPath, "dest", destPath, "size", srcInfo.Size())
+	sm.log.Debug("File copied successfully", "src", srcPath, "dest", destPath, "size", srcInfo.Size())
 	return nil
 }
 
@@ -384,6 +606,114 @@ func (sm *SyncManager) shouldSkipFile(path string) bool {
 	return false
 }
 
+// replayUnacked re-delivers any journaled event whose seq is still above
+// the acked cursor, so a crash between journaling and processing doesn't
+// silently lose it.
+func (sm *SyncManager) replayUnacked(ctx context.Context) {
+	entries, err := sm.journal.ReplayUnacked()
+	if err != nil {
+		sm.log.Error("Failed to read journal for replay", "error", err)
+		return
+	}
+	if len(entries) == 0 {
+		return
+	}
+
+	sm.log.Info("Replaying unacked journal entries", "count", len(entries))
+	for _, entry := range entries {
+		sm.recordJournalStat(func(s *WatcherStats) { s.Replayed++ })
+		sm.handleFileEvent(ctx, entry.Event)
+		if err := sm.journal.Ack(entry.Seq); err != nil {
+			sm.log.Warn("Failed to ack replayed journal entry", "seq", entry.Seq, "error", err)
+		}
+	}
+}
+
+// reconcile walks the configured roots, diffs them against the snapshot
+// persisted at the end of the previous run, and processes synthetic events
+// for anything that changed while the watcher was down.
+func (sm *SyncManager) reconcile(ctx context.Context) {
+	journalPath, err := sm.config.getJournalPath()
+	if err != nil {
+		sm.log.Warn("Could not resolve journal path for reconciliation", "error", err)
+		return
+	}
+	snapshotPath := reconcileSnapshotPath(journalPath)
+
+	previous, err := loadReconcileSnapshot(snapshotPath)
+	if err != nil {
+		sm.log.Warn("Could not load reconciliation snapshot, skipping", "error", err)
+		return
+	}
+
+	filter, err := newEventFilter(sm.config)
+	if err != nil {
+		sm.log.Warn("Could not build filter for reconciliation, skipping", "error", err)
+		return
+	}
+
+	var roots []string
+	if icloudPath, err := sm.config.getICloudPath(); err == nil {
+		roots = append(roots, icloudPath)
+	}
+	if outputPath, err := sm.config.getOutputPath(); err == nil {
+		if _, statErr := os.Stat(outputPath); statErr == nil {
+			roots = append(roots, outputPath)
+		}
+	}
+	if len(roots) == 0 {
+		return
+	}
+
+	events, current := reconcileScan(roots, previous, filter)
+	if len(events) > 0 {
+		sm.log.Info("Reconciliation found changes made while offline", "count", len(events))
+		for _, event := range events {
+			sm.journalAndHandle(ctx, event)
+		}
+	}
+
+	if err := saveReconcileSnapshot(snapshotPath, current); err != nil {
+		sm.log.Warn("Failed to save reconciliation snapshot", "error", err)
+	}
+}
+
+// recordJournalStat updates the journal-related WatcherStats counters
+// under lock.
+func (sm *SyncManager) recordJournalStat(update func(*WatcherStats)) {
+	sm.journalStatsMu.Lock()
+	defer sm.journalStatsMu.Unlock()
+	update(&sm.journalStats)
+}
+
+// JournalStats returns a snapshot of the journal's event counters.
+func (sm *SyncManager) JournalStats() WatcherStats {
+	sm.journalStatsMu.Lock()
+	defer sm.journalStatsMu.Unlock()
+	return sm.journalStats
+}
+
+// journalAndHandle persists event to the journal, processes it, and acks
+// it once processing returns - the at-least-once delivery path shared by
+// live watcher events and reconciliation's synthetic ones.
+func (sm *SyncManager) journalAndHandle(ctx context.Context, event FileEvent) {
+	seq, err := sm.journal.Append(event)
+	if err != nil {
+		sm.log.Error("Failed to journal event, processing without durability", "path", event.Path, "error", err)
+		sm.recordJournalStat(func(s *WatcherStats) { s.Dropped++ })
+		sm.handleFileEvent(ctx, event)
+		return
+	}
+	sm.recordJournalStat(func(s *WatcherStats) { s.Journaled++ })
+
+	event.JournalSeq = seq
+	sm.handleFileEvent(ctx, event)
+
+	if err := sm.journal.Ack(seq); err != nil {
+		sm.log.Warn("Failed to ack journal entry", "seq", seq, "error", err)
+	}
+}
+
 // processFileEvents processes file system events from the watcher
 func (sm *SyncManager) processFileEvents(ctx context.Context) {
 	eventChan := sm.watcher.GetEventChannel()
@@ -391,86 +721,161 @@ func (sm *SyncManager) processFileEvents(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
-			logger.Info("File event processing stopped")
+			sm.log.Info("File event processing stopped")
 			return
 
 		case event, ok := <-eventChan:
 			if !ok {
-				logger.Info("Event channel closed")
+				sm.log.Info("Event channel closed")
 				return
 			}
 
-			sm.handleFileEvent(event)
+			sm.journalAndHandle(ctx, event)
 		}
 	}
 }
 
-// handleFileEvent processes a single file event
-func (sm *SyncManager) handleFileEvent(event FileEvent) {
-	// Skip if already syncing to avoid loops
-	if sm.syncing {
-		logger.Debug("Sync in progress, skipping event", "path", event.Path)
+// handleFileEvent processes a single file event. Events on the same path
+// are serialized via pathLocks so they're applied in order; events on
+// different paths proceed concurrently. The lock key here is the event's
+// absolute path, while the upload/copy pools lock on relative paths.
+func (sm *SyncManager) handleFileEvent(ctx context.Context, event FileEvent) {
+	// Skip if a full sync pass is in progress, to avoid racing a walk
+	// that's already touching the same files.
+	if sm.syncingFlag.Load() {
+		sm.log.Debug("Sync in progress, skipping event", "path", event.Path)
 		return
 	}
 
-	logger.Debug("Processing file event", "path", event.Path, "op", event.Operation)
+	release := sm.pathLocks.Lock(event.Path)
+	defer release()
+
+	sm.log.Debug("Processing file event", "path", event.Path, "op", event.Operation)
+
+	err := sm.withLock(func() error {
+		sm.dispatchEvent(ctx, event)
+		return nil
+	})
+	switch {
+	case errors.Is(err, errLockBusy):
+		sm.log.Warn("Skipping file event: run lock held by another process", "path", event.Path)
+	case err != nil:
+		sm.log.Warn("Could not acquire run lock for file event, proceeding unlocked", "path", event.Path, "error", err)
+		sm.dispatchEvent(ctx, event)
+	}
+}
+
+// dispatchEvent routes event to the iCloud or TM-outputs handler based on
+// which tree it falls under.
+func (sm *SyncManager) dispatchEvent(ctx context.Context, event FileEvent) {
+	if sm.isInICloudPath(event.Path) {
+		sm.handleICloudEvent(ctx, event)
+	} else if sm.isInOutputPath(event.Path) {
+		sm.handleOutputEvent(ctx, event)
+	}
+}
+
+// isInICloudPath checks if a path is within the configured iCloud directory
+func (sm *SyncManager) isInICloudPath(path string) bool {
+	icloudPath, err := sm.config.getICloudPath()
+	if err != nil {
+		return false
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
 
-	// Determine sync direction based on event path
-	if sm.watcher.isInICloudPath(event.Path) {
-		sm.handleICloudEvent(event)
-	} else if sm.watcher.isInOutputPath(event.Path) {
-		sm.handleOutputEvent(event)
+	absICloudPath, err := filepath.Abs(icloudPath)
+	if err != nil {
+		return false
 	}
+
+	return strings.HasPrefix(absPath, absICloudPath)
+}
+
+// isInOutputPath checks if a path is within the TM outputs directory
+func (sm *SyncManager) isInOutputPath(path string) bool {
+	outputPath, err := sm.config.getOutputPath()
+	if err != nil {
+		sm.log.Warn("Could not get output path for checking", "error", err)
+		return false
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+
+	absOutputPath, err := filepath.Abs(outputPath)
+	if err != nil {
+		return false
+	}
+
+	return strings.HasPrefix(absPath, absOutputPath)
 }
 
 // handleICloudEvent handles events from iCloud Drive
-func (sm *SyncManager) handleICloudEvent(event FileEvent) {
+func (sm *SyncManager) handleICloudEvent(ctx context.Context, event FileEvent) {
+	log := sm.log
+	if sc := FromContext(ctx); sc != nil && sc.Log != nil {
+		log = sc.Log
+	}
+
 	icloudPath, err := sm.config.getICloudPath()
 	if err != nil {
-		logger.Error("Failed to get iCloud path", "error", err)
+		log.Error("Failed to get iCloud path", "error", err)
 		return
 	}
 
 	// Calculate relative path
 	relPath, err := filepath.Rel(icloudPath, event.Path)
 	if err != nil {
-		logger.Error("Failed to calculate relative path", "path", event.Path, "error", err)
+		log.Error("Failed to calculate relative path", "path", event.Path, "error", err)
 		return
 	}
 
 	// Handle based on operation
 	if strings.Contains(event.Operation, "CREATE") || strings.Contains(event.Operation, "WRITE") {
 		if !event.IsDir {
-			err = sm.uploadFile(event.Path, relPath)
+			err = sm.uploadFileDelta(icloudPath, event.Path, relPath)
 			if err != nil {
-				logger.Error("Failed to upload from iCloud", "path", event.Path, "error", err)
+				log.Error("Failed to upload from iCloud", "path", event.Path, "error", err)
+			} else {
+				sm.mirrorToDestinations(ctx, event.Path, relPath)
 			}
 		}
 	} else if strings.Contains(event.Operation, "REMOVE") {
 		// TODO: Implement file deletion on the server if needed
-		logger.Info("File removed in iCloud, no action taken on server", "path", relPath)
+		log.Info("File removed in iCloud, no action taken on server", "path", relPath)
 	}
 }
 
 // handleOutputEvent handles events from TM outputs
-func (sm *SyncManager) handleOutputEvent(event FileEvent) {
+func (sm *SyncManager) handleOutputEvent(ctx context.Context, event FileEvent) {
+	log := sm.log
+	if sc := FromContext(ctx); sc != nil && sc.Log != nil {
+		log = sc.Log
+	}
+
 	outputPath, err := sm.config.getOutputPath()
 	if err != nil {
-		logger.Error("Failed to get output path", "error", err)
+		log.Error("Failed to get output path", "error", err)
 		return
 	}
 
 	// Calculate relative path
 	relPath, err := filepath.Rel(outputPath, event.Path)
 	if err != nil {
-		logger.Error("Failed to calculate relative path", "path", event.Path, "error", err)
+		log.Error("Failed to calculate relative path", "path", event.Path, "error", err)
 		return
 	}
 
 	// Calculate destination path in iCloud
 	icloudPath, err := sm.config.getICloudPath()
 	if err != nil {
-		logger.Error("Failed to get iCloud path", "error", err)
+		log.Error("Failed to get iCloud path", "error", err)
 		return
 	}
 
@@ -485,59 +890,154 @@ func (sm *SyncManager) handleOutputEvent(event FileEvent) {
 		}
 
 		if err != nil {
-			logger.Error("Failed to sync to iCloud", "src", event.Path, "dest", destPath, "error", err)
+			log.Error("Failed to sync to iCloud", "src", event.Path, "dest", destPath, "error", err)
 		} else {
-			logger.Info("Synced to iCloud", "src", event.Path, "dest", destPath)
+			log.Info("Synced to iCloud", "src", event.Path, "dest", destPath)
 		}
 	} else if strings.Contains(event.Operation, "REMOVE") {
 		err = os.RemoveAll(destPath)
 		if err != nil {
-			logger.Error("Failed to remove file", "path", destPath, "error", err)
+			log.Error("Failed to remove file", "path", destPath, "error", err)
+			sm.audit.Record(AuditEvent{Type: AuditError, Path: relPath, Direction: "outputs_to_icloud", Error: err.Error()})
 		} else {
-			logger.Info("Removed file", "path", destPath)
+			log.Info("Removed file", "path", destPath)
+			sm.audit.Record(AuditEvent{Type: AuditDelete, Path: relPath, Direction: "outputs_to_icloud"})
 		}
 	}
 }
 
 // periodicSync performs periodic full sync
 func (sm *SyncManager) periodicSync(ctx context.Context) {
-	ticker := time.NewTicker(time.Duration(sm.config.SyncInterval) * time.Second)
+	interval := time.Duration(sm.config.SyncInterval) * time.Second
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
-			logger.Info("Periodic sync stopped")
+			sm.log.Info("Periodic sync stopped")
 			return
 
 		case <-ticker.C:
-			logger.Debug("Performing periodic sync")
-			sm.syncing = true
+			if next := time.Duration(sm.config.SyncInterval) * time.Second; next != interval {
+				interval = next
+				ticker.Reset(interval)
+			}
+
+			sm.log.Debug("Performing periodic sync")
+			sm.syncingFlag.Store(true)
 
 			err := sm.performInitialSync()
 			if err != nil {
-				logger.Error("Periodic sync failed", "error", err)
+				sm.log.Error("Periodic sync failed", "error", err)
 			}
 
-			sm.syncing = false
+			sm.syncingFlag.Store(false)
+
+			if err := sm.journal.Compact(); err != nil {
+				sm.log.Warn("Journal compaction failed", "error", err)
+			}
 		}
 	}
 }
 
 // Stop stops the sync manager
 func (sm *SyncManager) Stop() error {
-	logger.Info("Stopping sync manager")
+	sm.log.Info("Stopping sync manager")
 
 	err := sm.watcher.Stop()
 	if err != nil {
 		return fmt.Errorf("failed to stop file watcher: %w", err)
 	}
 
-	logger.Info("Sync manager stopped")
+	if err := sm.journal.Close(); err != nil {
+		sm.log.Warn("Failed to close event journal", "error", err)
+	}
+
+	sm.uploadPool.Close()
+	sm.copyPool.Close()
+
+	if err := sm.audit.Close(); err != nil {
+		sm.log.Warn("Failed to close audit log", "error", err)
+	}
+
+	sm.log.Info("Sync manager stopped")
+	return nil
+}
+
+// ReplayFrom reads every journal entry timestamped at or after since and
+// re-processes it, for manual re-processing via the --replay-from CLI
+// flag. Unlike replayUnacked, this ignores the acked cursor entirely.
+func (sm *SyncManager) ReplayFrom(since time.Time) error {
+	entries, err := sm.journal.ReplayFrom(since)
+	if err != nil {
+		return fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	sm.log.Info("Replaying journal entries from timestamp", "since", since, "count", len(entries))
+	ctx := WithSync(context.Background(), &SyncContext{
+		Config:     sm.config,
+		Log:        sm.log,
+		HTTPClient: &http.Client{Timeout: time.Second * 30},
+		Providers:  sm.providers,
+	})
+	for _, entry := range entries {
+		sm.handleFileEvent(ctx, entry.Event)
+	}
+	return nil
+}
+
+// ReloadConfig applies sync_interval, log_level, backup_enabled and
+// api_endpoint from newConfig onto the running configuration without
+// restarting the process - periodicSync picks up a changed SyncInterval
+// on its next tick. A change to icloud_parent_folder, journal_path,
+// watch_mode, watcher_backend or admin_addr is rejected (see
+// requireHotSwappable), since the watcher, journal, run lock and admin
+// server are set up from those at startup. A change to any other field is
+// neither applied nor rejected.
+func (sm *SyncManager) ReloadConfig(newConfig *Config) error {
+	if err := requireHotSwappable(sm.config, newConfig); err != nil {
+		return err
+	}
+
+	sm.config.SyncInterval = newConfig.SyncInterval
+	sm.config.LogLevel = newConfig.LogLevel
+	sm.config.BackupEnabled = newConfig.BackupEnabled
+	sm.config.ApiEndpoint = newConfig.ApiEndpoint
+
+	InitLogger(sm.config.LogLevel, sm.config.LogFormat)
+
+	return nil
+}
+
+// requireHotSwappable rejects a reload if any field ReloadConfig can't
+// apply live differs between the running and newly loaded configuration.
+func requireHotSwappable(current, next *Config) error {
+	switch {
+	case current.ICloudParentFolder != next.ICloudParentFolder:
+		return fmt.Errorf("icloud_parent_folder cannot be changed without a restart")
+	case current.JournalPath != next.JournalPath:
+		return fmt.Errorf("journal_path cannot be changed without a restart")
+	case current.WatchMode != next.WatchMode:
+		return fmt.Errorf("watch_mode cannot be changed without a restart")
+	case current.WatcherBackend != next.WatcherBackend:
+		return fmt.Errorf("watcher_backend cannot be changed without a restart")
+	case current.AdminAddr != next.AdminAddr:
+		return fmt.Errorf("admin_addr cannot be changed without a restart")
+	}
 	return nil
 }
 
-// GetStats returns current sync statistics
+// GetStats returns current sync statistics, including the upload/copy
+// worker pools' live InFlight and Queued counts.
 func (sm *SyncManager) GetStats() SyncStats {
-	return sm.syncStats
+	sm.syncStatsMu.Lock()
+	stats := sm.syncStats
+	sm.syncStatsMu.Unlock()
+
+	uploadInFlight, uploadQueued := sm.uploadPool.Stats()
+	copyInFlight, copyQueued := sm.copyPool.Stats()
+	stats.InFlight = uploadInFlight + copyInFlight
+	stats.Queued = uploadQueued + copyQueued
+	return stats
 }
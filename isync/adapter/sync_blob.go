@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// hashFile streams filePath through SHA-256, returning the hex digest and
+// the byte count, without holding the whole file in memory.
+func hashFile(filePath string) (hash string, size int64, err error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", 0, fmt.Errorf("could not open file: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, fmt.Errorf("could not hash file: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// currentUploaderID identifies this machine in blob sidecars, falling back
+// to "unknown" when the hostname can't be read.
+func currentUploaderID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return "unknown"
+	}
+	return host
+}
+
+// blobStoreFor lazily creates (or reuses) the BlobStore for root, caching
+// it on the SyncManager since it's consulted on every upload.
+func (sm *SyncManager) blobStoreFor(root string) (*BlobStore, error) {
+	sm.blobStoreMu.Lock()
+	defer sm.blobStoreMu.Unlock()
+
+	if sm.blobStore != nil {
+		return sm.blobStore, nil
+	}
+
+	store, err := newBlobStore(root)
+	if err != nil {
+		return nil, err
+	}
+	sm.blobStore = store
+	return store, nil
+}
+
+// blobExists asks the server (HEAD /blob/<hash>) whether it already has
+// this content, so an unchanged file doesn't need its bytes re-sent.
+func (sm *SyncManager) blobExists(hash string) (bool, error) {
+	req, err := http.NewRequest("HEAD", sm.apiBase()+"/blob/"+hash, nil)
+	if err != nil {
+		return false, fmt.Errorf("could not create blob HEAD request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+sm.config.ApiKey)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("blob HEAD request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode == http.StatusOK, nil
+}
+
+// uploadBlob POSTs filePath's raw bytes to /blob/<hash>.
+func (sm *SyncManager) uploadBlob(filePath, hash string, size int64) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("could not open file for blob upload: %w", err)
+	}
+	defer f.Close()
+
+	req, err := http.NewRequest("POST", sm.apiBase()+"/blob/"+hash, f)
+	if err != nil {
+		return fmt.Errorf("could not create blob upload request: %w", err)
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Authorization", "Bearer "+sm.config.ApiKey)
+
+	client := &http.Client{Timeout: 5 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("blob upload request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("blob upload failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// createRef binds relPath (at version) to hash, so the server knows which
+// blob is the current content for that path without needing to re-derive
+// it from upload order.
+func (sm *SyncManager) createRef(relPath, hash string, version int, origModTime time.Time) error {
+	payload, err := json.Marshal(struct {
+		RelativePath string    `json:"relative_path"`
+		SHA256       string    `json:"sha256"`
+		Version      int       `json:"version"`
+		OrigModTime  time.Time `json:"orig_mod_time"`
+	}{
+		RelativePath: relPath,
+		SHA256:       hash,
+		Version:      version,
+		OrigModTime:  origModTime,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal ref payload: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", sm.apiBase()+"/ref", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("could not create ref request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+sm.config.ApiKey)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ref request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ref request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// blobPruneInterval is how often the retention pruner re-walks the blob
+// store looking for archived versions past Config.RetentionDays.
+const blobPruneInterval = 24 * time.Hour
+
+// blobPruner runs PruneOrphaned on a timer until ctx is cancelled. It's a
+// no-op when RetentionDays isn't configured.
+func (sm *SyncManager) blobPruner(ctx context.Context) {
+	if sm.config.RetentionDays <= 0 {
+		return
+	}
+
+	sm.pruneBlobs()
+
+	ticker := time.NewTicker(blobPruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sm.pruneBlobs()
+		}
+	}
+}
+
+func (sm *SyncManager) pruneBlobs() {
+	root, err := sm.config.getICloudPath()
+	if err != nil {
+		return
+	}
+
+	store, err := sm.blobStoreFor(root)
+	if err != nil {
+		sm.log.Warn("Could not open blob store for pruning", "error", err)
+		return
+	}
+
+	pruned, err := store.PruneOrphaned(sm.config.RetentionDays)
+	if err != nil {
+		sm.log.Warn("Blob retention prune failed", "error", err)
+		return
+	}
+	if pruned > 0 {
+		sm.log.Info("Pruned orphaned blobs past retention window", "count", pruned, "retention_days", sm.config.RetentionDays)
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// manifestSidecarDir is the per-iCloud-root directory manifests and the
+// conflict sidecars created during delta sync live under.
+const manifestSidecarDir = ".tm-sync"
+
+// chunkPatch is one chunk sent to the server's patch endpoint.
+type chunkPatch struct {
+	Index int    `json:"index"`
+	Data  string `json:"data"` // base64-encoded chunk bytes
+}
+
+// manifestsFor lazily creates (or reuses) the ManifestStore for root,
+// caching it on the SyncManager since it's read on every upload attempt.
+func (sm *SyncManager) manifestsFor(root string) (*ManifestStore, error) {
+	sm.manifestsMu.Lock()
+	defer sm.manifestsMu.Unlock()
+
+	if sm.manifests != nil {
+		return sm.manifests, nil
+	}
+
+	sidecarDir := filepath.Join(root, manifestSidecarDir)
+	if err := os.MkdirAll(sidecarDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create manifest sidecar directory: %w", err)
+	}
+
+	store, err := newManifestStore(filepath.Join(sidecarDir, "manifests.json"))
+	if err != nil {
+		return nil, err
+	}
+	sm.manifests = store
+	return store, nil
+}
+
+// apiBase returns ApiEndpoint with its final path segment stripped, so
+// sibling endpoints like /manifest and /patch can be derived from the
+// configured upload endpoint (e.g. ".../api/icloud/upload" ->
+// ".../api/icloud").
+func (sm *SyncManager) apiBase() string {
+	return strings.TrimSuffix(sm.config.ApiEndpoint, "/"+pathLastSegment(sm.config.ApiEndpoint))
+}
+
+func pathLastSegment(p string) string {
+	idx := strings.LastIndex(p, "/")
+	if idx < 0 {
+		return p
+	}
+	return p[idx+1:]
+}
+
+// fetchServerManifest asks the server for relPath's manifest. ok is false
+// (with a nil error) when the server doesn't advertise manifest support
+// (404), signaling the caller to fall back to a full upload.
+func (sm *SyncManager) fetchServerManifest(relPath string) (manifest *FileManifest, ok bool, err error) {
+	endpoint := sm.apiBase() + "/manifest?path=" + url.QueryEscape(relPath)
+
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("could not create manifest request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+sm.config.ApiKey)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		// Treat transport errors (server doesn't exist, connection refused)
+		// as "manifest support unavailable" rather than a hard failure -
+		// the caller falls back to the full-upload path.
+		return nil, false, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, false, fmt.Errorf("manifest request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var m FileManifest
+	if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
+		return nil, false, fmt.Errorf("failed to decode manifest response: %w", err)
+	}
+	return &m, true, nil
+}
+
+// sendPatch uploads only the chunks in changedIndexes, reassembled
+// server-side against relPath's existing content.
+func (sm *SyncManager) sendPatch(filePath, relPath string, local *FileManifest, changedIndexes []int) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("could not open file for patching: %w", err)
+	}
+	defer f.Close()
+
+	changed := make(map[int]bool, len(changedIndexes))
+	for _, idx := range changedIndexes {
+		changed[idx] = true
+	}
+
+	var patches []chunkPatch
+	buf := make([]byte, chunkSize)
+	for index := 0; ; index++ {
+		n, readErr := io.ReadFull(f, buf)
+		if n > 0 && changed[index] {
+			patches = append(patches, chunkPatch{Index: index, Data: base64.StdEncoding.EncodeToString(buf[:n])})
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("failed to read file for patching: %w", readErr)
+		}
+	}
+
+	payload, err := json.Marshal(struct {
+		RelativePath string       `json:"relative_path"`
+		Size         int64        `json:"size"`
+		FullHash     string       `json:"full_hash"`
+		Chunks       []chunkPatch `json:"chunks"`
+	}{
+		RelativePath: relPath,
+		Size:         local.Size,
+		FullHash:     local.FullHash,
+		Chunks:       patches,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal patch payload: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", sm.apiBase()+"/patch", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("could not create patch request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+sm.config.ApiKey)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("patch request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("patch failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// uploadFileDelta uploads filePath using the manifest/chunk-diff path when
+// the server supports it, falling back to the original full multipart
+// upload (uploadFile) otherwise. It also guards against silently
+// clobbering a concurrent remote edit: if the server's manifest has moved
+// on from what we last recorded locally *and* our own copy has also
+// changed since then, the local copy is preserved as a conflict sidecar
+// before the upload proceeds.
+func (sm *SyncManager) uploadFileDelta(root, filePath, relPath string) error {
+	local, err := computeManifest(relPath, filePath)
+	if err != nil {
+		return fmt.Errorf("failed to compute local manifest: %w", err)
+	}
+
+	store, err := sm.manifestsFor(root)
+	if err != nil {
+		sm.log.Warn("Could not open manifest store, falling back to full upload", "error", err)
+		return sm.uploadFile(filePath, relPath)
+	}
+
+	remote, supported, err := sm.fetchServerManifest(relPath)
+	if err != nil {
+		sm.log.Warn("Manifest request failed, falling back to full upload", "path", relPath, "error", err)
+		return sm.uploadFile(filePath, relPath)
+	}
+	if !supported {
+		sm.log.Debug("Server does not advertise manifest support, using full upload", "path", relPath)
+		return sm.uploadFile(filePath, relPath)
+	}
+
+	if remote.FullHash == local.FullHash {
+		sm.log.Debug("File unchanged on server, skipping upload", "path", relPath)
+		return store.Put(relPath, *local)
+	}
+
+	if lastSynced, ok := store.Get(relPath); ok &&
+		lastSynced.FullHash != remote.FullHash && lastSynced.FullHash != local.FullHash {
+		if err := sm.writeConflictSidecar(filePath); err != nil {
+			sm.log.Warn("Failed to write conflict sidecar", "path", filePath, "error", err)
+		} else {
+			sm.log.Warn("Concurrent edit detected, preserved local copy as conflict sidecar", "path", relPath)
+			sm.audit.Record(AuditEvent{Type: AuditConflict, Path: relPath, Direction: "icloud_to_server"})
+		}
+	}
+
+	changed := diffChunks(local, remote)
+	if len(changed) == 0 {
+		return store.Put(relPath, *local)
+	}
+
+	if err := sm.sendPatch(filePath, relPath, local, changed); err != nil {
+		return fmt.Errorf("failed to send patch: %w", err)
+	}
+
+	sm.log.Info("Uploaded via delta patch", "path", relPath, "chunks_sent", len(changed), "chunks_total", len(local.Chunks))
+	return store.Put(relPath, *local)
+}
+
+// writeConflictSidecar copies filePath to "<filePath>.conflict-<unix ts>"
+// so a concurrently-edited local copy isn't lost when the upload that
+// follows reconciles against the server's version.
+func (sm *SyncManager) writeConflictSidecar(filePath string) error {
+	sidecarPath := fmt.Sprintf("%s.conflict-%d", filePath, time.Now().Unix())
+	return sm.copyFile(filePath, sidecarPath)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"net/http"
+)
+
+// SyncContext bundles the state SyncManager's request-scoped helpers need
+// - a config snapshot, a logger, the HTTP client used for uploads, and the
+// provider registry - so they can be threaded through a context.Context
+// instead of read off package globals or a single shared SyncManager.
+// This is what lets more than one SyncManager run in the same process
+// (e.g. one per matter, each with its own endpoint and credentials)
+// without their logging or config bleeding into each other.
+type SyncContext struct {
+	Config     *Config
+	Log        *Logger
+	HTTPClient *http.Client
+	Providers  map[string]Provider
+}
+
+type syncContextKey struct{}
+
+// WithSync attaches sc to ctx.
+func WithSync(ctx context.Context, sc *SyncContext) context.Context {
+	return context.WithValue(ctx, syncContextKey{}, sc)
+}
+
+// FromContext retrieves the SyncContext attached by WithSync, or nil if
+// none was attached.
+func FromContext(ctx context.Context) *SyncContext {
+	sc, _ := ctx.Value(syncContextKey{}).(*SyncContext)
+	return sc
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// hardcodedSkipDirs and hardcodedSkipFilePatterns are the original,
+// non-configurable skip lists. They stay as a baseline underneath
+// Config.IgnoreGlobs/IncludeGlobs so existing setups keep working even with
+// an empty config.
+var hardcodedSkipDirs = []string{
+	".git", ".svn", ".hg",
+	"node_modules", "__pycache__", ".pytest_cache",
+	"venv", ".venv", "env", ".env",
+	".DS_Store", "Thumbs.db",
+	".tmp", "tmp", "temp",
+	".Trash", ".Trashes",
+}
+
+var hardcodedSkipFilePatterns = []string{
+	".DS_Store", "Thumbs.db", ".tmp", ".temp",
+	".swp", ".swo", "~", ".lock", ".pid",
+}
+
+// eventFilter is the skip/allow decision logic shared by FileWatcher and
+// PollingFileWatcher, plus the WatcherStats counters tracking what got
+// filtered and why. Config's globs are compiled once here rather than
+// re-parsed on every event.
+type eventFilter struct {
+	ignoreGlobs  []string
+	includeGlobs []string
+
+	statsMu sync.Mutex
+	stats   WatcherStats
+}
+
+func newEventFilter(config *Config) (*eventFilter, error) {
+	ignoreGlobs, err := compileGlobs(config.IgnoreGlobs)
+	if err != nil {
+		return nil, fmt.Errorf("ignore_globs: %w", err)
+	}
+	includeGlobs, err := compileGlobs(config.IncludeGlobs)
+	if err != nil {
+		return nil, fmt.Errorf("include_globs: %w", err)
+	}
+	return &eventFilter{ignoreGlobs: ignoreGlobs, includeGlobs: includeGlobs}, nil
+}
+
+// shouldSkipDirectory determines if a directory should be skipped.
+// IgnoreGlobs is checked first, on top of the hardcoded list; IncludeGlobs
+// is a file-only allow-list and doesn't apply to directories.
+func (f *eventFilter) shouldSkipDirectory(path string) bool {
+	base := filepath.Base(path)
+
+	if matchesAnyGlob(f.ignoreGlobs, base) {
+		f.recordSkip(true)
+		return true
+	}
+
+	for _, skip := range hardcodedSkipDirs {
+		if base == skip || strings.HasPrefix(base, ".") && len(base) > 1 {
+			f.recordSkip(false)
+			return true
+		}
+	}
+
+	return false
+}
+
+// shouldSkipFile determines if a file event should be skipped. An
+// IgnoreGlobs match skips the file outright; when IncludeGlobs is non-empty
+// it acts as an allow-list - a file not matching any of them is skipped
+// even if it would otherwise pass.
+func (f *eventFilter) shouldSkipFile(path string) bool {
+	base := filepath.Base(path)
+
+	if matchesAnyGlob(f.ignoreGlobs, base) {
+		f.recordSkip(true)
+		return true
+	}
+	if len(f.includeGlobs) > 0 && !matchesAnyGlob(f.includeGlobs, base) {
+		f.recordSkip(true)
+		return true
+	}
+
+	for _, pattern := range hardcodedSkipFilePatterns {
+		if strings.Contains(base, pattern) || strings.HasSuffix(base, pattern) {
+			f.recordSkip(false)
+			return true
+		}
+	}
+
+	if strings.HasPrefix(base, "._") {
+		f.recordSkip(false)
+		return true
+	}
+
+	f.statsMu.Lock()
+	f.stats.Matched++
+	f.statsMu.Unlock()
+	return false
+}
+
+// recordSkip updates WatcherStats for something that was skipped,
+// distinguishing a user-configured glob match from the hardcoded list.
+func (f *eventFilter) recordSkip(byGlob bool) {
+	f.statsMu.Lock()
+	defer f.statsMu.Unlock()
+	if byGlob {
+		f.stats.SkippedByGlob++
+	} else {
+		f.stats.SkippedByHardcoded++
+	}
+}
+
+// recordDrop updates WatcherStats for an event dropped because eventChan's
+// buffer was full.
+func (f *eventFilter) recordDrop() {
+	f.statsMu.Lock()
+	f.stats.DroppedFullBuffer++
+	f.statsMu.Unlock()
+}
+
+// Stats returns a snapshot of the filter's event classification counters.
+func (f *eventFilter) Stats() WatcherStats {
+	f.statsMu.Lock()
+	defer f.statsMu.Unlock()
+	return f.stats
+}
@@ -6,17 +6,74 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
 )
 
-// FileEvent represents a file system event
+// FileEvent represents a file system event. Ops holds the distinct fsnotify
+// Op values folded together during the debounce window (see debounceEvent);
+// Operation is the same set joined with "|" for quick logging/matching.
+//
+// The FSEvents* fields are only populated by NotifyWatcher, which has direct
+// access to the platform-level rename/create flags rjeczalik/notify exposes
+// on macOS; every other backend leaves them false, so a caller that wants to
+// distinguish a Finder rename from a delete+create without a follow-up
+// os.Stat needs Config.WatcherBackend set to "notify".
 type FileEvent struct {
 	Path      string
 	Operation string
+	Ops       []string
 	IsDir     bool
 	Timestamp time.Time
+
+	FSEventsIsDir     bool
+	FSEventsIsFile    bool
+	FSEventsIsSymlink bool
+	FSEventsCreated   bool
+	FSEventsRenamed   bool
+
+	// JournalSeq is assigned by EventJournal.Append when SyncManager
+	// journals this event, and is what SyncManager.Ack's afterwards. Zero
+	// for events that were never journaled (e.g. constructed in tests).
+	JournalSeq int64 `json:"-"`
+}
+
+// pendingEvent accumulates fsnotify Op flags for one path while its
+// debounce timer is running.
+type pendingEvent struct {
+	ops   map[fsnotify.Op]bool
+	timer *time.Timer
+}
+
+// WatcherStats tracks how a watcher's skip/allow logic has classified
+// events, for diagnosing why a file a user expects to sync never shows up
+// (or why an unwanted file keeps triggering syncs).
+type WatcherStats struct {
+	Matched            int64
+	SkippedByGlob      int64
+	SkippedByHardcoded int64
+	DroppedFullBuffer  int64
+
+	// Dropped, Journaled, and Replayed are maintained by SyncManager's
+	// EventJournal rather than by a Watcher's eventFilter: Dropped counts
+	// events that failed to persist to the journal, Journaled counts ones
+	// that did, and Replayed counts unacked entries re-delivered at
+	// startup. See EventJournal.
+	Dropped   int64
+	Journaled int64
+	Replayed  int64
+}
+
+// Watcher is implemented by anything that can watch the configured iCloud
+// and outputs directories and produce FileEvents for changes under them.
+// FileWatcher (fsnotify-backed) and PollingFileWatcher (stat-poll-backed)
+// both satisfy it, and HybridWatcher composes the two.
+type Watcher interface {
+	Start(ctx context.Context) error
+	GetEventChannel() <-chan FileEvent
+	Stop() error
 }
 
 // FileWatcher handles file system monitoring
@@ -25,6 +82,15 @@ type FileWatcher struct {
 	config    *Config
 	eventChan chan FileEvent
 	done      chan bool
+	filter    *eventFilter
+	log       *Logger
+
+	// debounce is the quiet window used to coalesce the Create/Write/Chmod
+	// (and sometimes Rename) burst fsnotify reports for a single save into
+	// one FileEvent. pending tracks the in-flight debounce timer per path.
+	debounce  time.Duration
+	pendingMu sync.Mutex
+	pending   map[string]*pendingEvent
 }
 
 // NewFileWatcher creates a new file watcher
@@ -34,14 +100,28 @@ func NewFileWatcher(config *Config) (*FileWatcher, error) {
 		return nil, fmt.Errorf("failed to create file watcher: %w", err)
 	}
 
+	filter, err := newEventFilter(config)
+	if err != nil {
+		return nil, err
+	}
+
 	return &FileWatcher{
 		watcher:   watcher,
 		config:    config,
 		eventChan: make(chan FileEvent, 100), // Buffer for events
 		done:      make(chan bool),
+		filter:    filter,
+		log:       logger.With("component", "watcher"),
+		debounce:  time.Duration(config.DebounceWindowMs) * time.Millisecond,
+		pending:   make(map[string]*pendingEvent),
 	}, nil
 }
 
+// Stats returns a snapshot of the watcher's event classification counters.
+func (fw *FileWatcher) Stats() WatcherStats {
+	return fw.filter.Stats()
+}
+
 // Start begins monitoring file system events
 func (fw *FileWatcher) Start(ctx context.Context) error {
 	// Get iCloud path
@@ -59,15 +139,15 @@ func (fw *FileWatcher) Start(ctx context.Context) error {
 	// Also watch TM outputs directory for reverse sync
 	outputPath, err := fw.config.getOutputPath()
 	if err != nil {
-		logger.Warn("Could not get output path, reverse sync might not work", "error", err)
+		fw.log.Warn("Could not get output path, reverse sync might not work", "error", err)
 	} else {
 		if _, err := os.Stat(outputPath); err == nil {
 			err = fw.addDirectoryRecursive(outputPath)
 			if err != nil {
-				logger.Warn("Failed to add outputs directory to watcher", "path", outputPath, "error", err)
+				fw.log.Warn("Failed to add outputs directory to watcher", "path", outputPath, "error", err)
 			}
 		}
-		logger.Info("File watcher started", "icloud_path", icloudPath, "output_path", outputPath)
+		fw.log.Info("File watcher started", "icloud_path", icloudPath, "output_path", outputPath)
 	}
 
 	// Start event processing goroutine
@@ -80,50 +160,37 @@ func (fw *FileWatcher) Start(ctx context.Context) error {
 func (fw *FileWatcher) addDirectoryRecursive(root string) error {
 	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
-			logger.Warn("Error walking directory", "path", path, "error", err)
+			fw.log.Warn("Error walking directory", "path", path, "error", err)
 			return nil // Continue walking despite errors
 		}
 
 		if info.IsDir() {
 			// Skip certain directories
 			if fw.shouldSkipDirectory(path) {
-				logger.Debug("Skipping directory", "path", path)
+				fw.log.Debug("Skipping directory", "path", path)
 				return filepath.SkipDir
 			}
 
 			err = fw.watcher.Add(path)
 			if err != nil {
-				logger.Warn("Failed to add directory to watcher", "path", path, "error", err)
+				fw.log.Warn("Failed to add directory to watcher", "path", path, "error", err)
 				return nil // Continue despite errors
 			}
-			logger.Debug("Added directory to watcher", "path", path)
+			fw.log.Debug("Added directory to watcher", "path", path)
 		}
 
 		return nil
 	})
 }
 
-// shouldSkipDirectory determines if a directory should be skipped
+// shouldSkipDirectory determines if a directory should be skipped.
 func (fw *FileWatcher) shouldSkipDirectory(path string) bool {
-	base := filepath.Base(path)
-	
-	// Skip hidden directories and common temp/system directories
-	skipDirs := []string{
-		".git", ".svn", ".hg",
-		"node_modules", "__pycache__", ".pytest_cache",
-		"venv", ".venv", "env", ".env",
-		".DS_Store", "Thumbs.db",
-		".tmp", "tmp", "temp",
-		".Trash", ".Trashes",
-	}
-
-	for _, skip := range skipDirs {
-		if base == skip || strings.HasPrefix(base, ".") && len(base) > 1 {
-			return true
-		}
-	}
+	return fw.filter.shouldSkipDirectory(path)
+}
 
-	return false
+// shouldSkipFile determines if a file event should be skipped.
+func (fw *FileWatcher) shouldSkipFile(path string) bool {
+	return fw.filter.shouldSkipFile(path)
 }
 
 // processEvents processes file system events
@@ -133,16 +200,16 @@ func (fw *FileWatcher) processEvents(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
-			logger.Info("File watcher stopped")
+			fw.log.Info("File watcher stopped")
 			return
 
 		case <-fw.done:
-			logger.Info("File watcher shutdown requested")
+			fw.log.Info("File watcher shutdown requested")
 			return
 
 		case event, ok := <-fw.watcher.Events:
 			if !ok {
-				logger.Error("Watcher events channel closed")
+				fw.log.Error("Watcher events channel closed")
 				return
 			}
 
@@ -150,82 +217,120 @@ func (fw *FileWatcher) processEvents(ctx context.Context) {
 
 		case err, ok := <-fw.watcher.Errors:
 			if !ok {
-				logger.Error("Watcher errors channel closed")
+				fw.log.Error("Watcher errors channel closed")
 				return
 			}
 
-			logger.Error("File watcher error", "error", err)
+			fw.log.Error("File watcher error", "error", err)
 		}
 	}
 }
 
-// handleEvent processes a single file system event
+// handleEvent processes a single raw fsnotify event
 func (fw *FileWatcher) handleEvent(event fsnotify.Event) {
 	// Skip temporary files and system files
 	if fw.shouldSkipFile(event.Name) {
-		logger.Debug("Skipping file event", "path", event.Name, "op", event.Op.String())
+		fw.log.Debug("Skipping file event", "path", event.Name, "op", event.Op.String())
 		return
 	}
 
-	// Get file info
-	fileInfo, err := os.Stat(event.Name)
-	isDir := false
-	if err == nil {
-		isDir = fileInfo.IsDir()
-	}
+	fw.log.Debug("Raw file event detected", "path", event.Name, "operation", event.Op.String())
 
-	// Create our custom event
-	fileEvent := FileEvent{
-		Path:      event.Name,
-		Operation: event.Op.String(),
-		IsDir:     isDir,
-		Timestamp: time.Now(),
+	// Handle directory creation immediately - adding it to the watcher races
+	// against files being created inside it, so this can't wait out the
+	// debounce window.
+	if event.Op&fsnotify.Create == fsnotify.Create {
+		if fileInfo, err := os.Stat(event.Name); err == nil && fileInfo.IsDir() {
+			if err := fw.addDirectoryRecursive(event.Name); err != nil {
+				fw.log.Warn("Failed to add new directory to watcher", "path", event.Name, "error", err)
+			}
+		}
 	}
 
-	logger.Info("File event detected", 
-		"path", event.Name, 
-		"operation", event.Op.String(), 
-		"is_dir", isDir)
+	fw.debounceEvent(event)
+}
 
-	// Handle directory creation - add to watcher
-	if event.Op&fsnotify.Create == fsnotify.Create && isDir {
-		err := fw.addDirectoryRecursive(event.Name)
-		if err != nil {
-			logger.Warn("Failed to add new directory to watcher", "path", event.Name, "error", err)
-		}
+// debounceEvent folds event into the pendingEvent tracked for its path and
+// (re)starts the quiet-window timer, so a burst of Create/Write/Chmod events
+// fsnotify reports for a single save collapses into one FileEvent.
+func (fw *FileWatcher) debounceEvent(event fsnotify.Event) {
+	fw.pendingMu.Lock()
+	defer fw.pendingMu.Unlock()
+
+	pending, exists := fw.pending[event.Name]
+	if !exists {
+		pending = &pendingEvent{ops: make(map[fsnotify.Op]bool)}
+		fw.pending[event.Name] = pending
 	}
+	pending.ops[event.Op] = true
 
-	// Send event to channel for processing
-	select {
-	case fw.eventChan <- fileEvent:
-		// Event sent successfully
-	default:
-		logger.Warn("Event channel full, dropping event", "path", event.Name)
+	if pending.timer != nil {
+		pending.timer.Stop()
 	}
+	path := event.Name
+	pending.timer = time.AfterFunc(fw.debounce, func() {
+		fw.flushPendingEvent(path)
+	})
 }
 
-// shouldSkipFile determines if a file event should be skipped
-func (fw *FileWatcher) shouldSkipFile(path string) bool {
-	base := filepath.Base(path)
-	
-	// Skip temporary files and system files
-	skipPatterns := []string{
-		".DS_Store", "Thumbs.db", ".tmp", ".temp",
-		".swp", ".swo", "~", ".lock", ".pid",
+// flushPendingEvent runs the stat-stability check for path - size and mtime
+// must be unchanged across one debounce window - and, once the file has
+// settled, emits a single FileEvent carrying the union of Ops observed
+// during the window. If the file is still changing the window is simply
+// extended rather than emitting a premature event.
+func (fw *FileWatcher) flushPendingEvent(path string) {
+	before, beforeErr := os.Stat(path)
+	time.Sleep(fw.debounce)
+	after, afterErr := os.Stat(path)
+
+	settled := beforeErr != nil && afterErr != nil
+	if beforeErr == nil && afterErr == nil {
+		settled = before.Size() == after.Size() && before.ModTime().Equal(after.ModTime())
 	}
 
-	for _, pattern := range skipPatterns {
-		if strings.Contains(base, pattern) || strings.HasSuffix(base, pattern) {
-			return true
-		}
+	fw.pendingMu.Lock()
+	defer fw.pendingMu.Unlock()
+
+	pending, exists := fw.pending[path]
+	if !exists {
+		return
 	}
 
-	// Skip files starting with ._
-	if strings.HasPrefix(base, "._") {
-		return true
+	if !settled {
+		pending.timer = time.AfterFunc(fw.debounce, func() {
+			fw.flushPendingEvent(path)
+		})
+		return
+	}
+	delete(fw.pending, path)
+
+	isDir := afterErr == nil && after.IsDir()
+
+	ops := make([]string, 0, len(pending.ops))
+	for op := range pending.ops {
+		ops = append(ops, op.String())
+	}
+
+	fileEvent := FileEvent{
+		Path:      path,
+		Operation: strings.Join(ops, "|"),
+		Ops:       ops,
+		IsDir:     isDir,
+		Timestamp: time.Now(),
 	}
 
-	return false
+	fw.log.Info("File event settled",
+		"path", path,
+		"operation", fileEvent.Operation,
+		"is_dir", isDir)
+
+	select {
+	case fw.eventChan <- fileEvent:
+		// Event sent successfully
+	default:
+		fw.log.Warn("Event channel full, dropping event", "path", path)
+		fw.filter.recordDrop()
+	}
 }
 
 // GetEventChannel returns the channel for receiving file events
@@ -235,58 +340,28 @@ func (fw *FileWatcher) GetEventChannel() <-chan FileEvent {
 
 // Stop stops the file watcher
 func (fw *FileWatcher) Stop() error {
-	logger.Info("Stopping file watcher")
-	
+	fw.log.Info("Stopping file watcher")
+
+	// Cancel any in-flight debounce timers so flushPendingEvent can't fire
+	// after processEvents has closed eventChan.
+	fw.pendingMu.Lock()
+	for path, pending := range fw.pending {
+		pending.timer.Stop()
+		delete(fw.pending, path)
+	}
+	fw.pendingMu.Unlock()
+
 	// Close the done channel to signal shutdown
 	close(fw.done)
-	
+
 	// Close the underlying watcher
 	err := fw.watcher.Close()
 	if err != nil {
 		return fmt.Errorf("failed to close file watcher: %w", err)
 	}
-	
-	logger.Info("File watcher stopped successfully")
-	return nil
-}
 
-// isInICloudPath checks if a path is within the iCloud directory
-func (fw *FileWatcher) isInICloudPath(path string) bool {
-	icloudPath, err := fw.config.getICloudPath()
-	if err != nil {
-		return false
-	}
-	
-	absPath, err := filepath.Abs(path)
-	if err != nil {
-		return false
-	}
-	
-	absICloudPath, err := filepath.Abs(icloudPath)
-	if err != nil {
-		return false
-	}
-	
-	return strings.HasPrefix(absPath, absICloudPath)
+	fw.log.Info("File watcher stopped successfully")
+	return nil
 }
 
-// isInOutputPath checks if a path is within the TM outputs directory
-func (fw *FileWatcher) isInOutputPath(path string) bool {
-	outputPath, err := fw.config.getOutputPath()
-	if err != nil {
-		logger.Warn("Could not get output path for checking", "error", err)
-		return false
-	}
-
-	absPath, err := filepath.Abs(path)
-	if err != nil {
-		return false
-	}
-
-	absOutputPath, err := filepath.Abs(outputPath)
-	if err != nil {
-		return false
-	}
-
-	return strings.HasPrefix(absPath, absOutputPath)
-}
\ No newline at end of file
+var _ Watcher = (*FileWatcher)(nil)
@@ -0,0 +1,23 @@
+package main
+
+import "fmt"
+
+// NewWatcher builds the Watcher backend selected by config.WatchMode
+// ("fsnotify", the default, "poll", or "hybrid"). For "fsnotify" mode,
+// config.WatcherBackend further selects between the fsnotify library
+// (default) and NotifyWatcher's native recursive watch.
+func NewWatcher(config *Config) (Watcher, error) {
+	switch config.WatchMode {
+	case "", "fsnotify":
+		if config.WatcherBackend == "notify" {
+			return NewNotifyWatcher(config)
+		}
+		return NewFileWatcher(config)
+	case "poll":
+		return NewPollingFileWatcher(config)
+	case "hybrid":
+		return NewHybridWatcher(config)
+	default:
+		return nil, fmt.Errorf("unknown watch_mode: %s (must be fsnotify, poll, or hybrid)", config.WatchMode)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import "sync"
+
+// workerPool fans out file-level work items across a bounded number of
+// goroutines with backpressure: Submit blocks once the job queue is full
+// rather than spawning a goroutine per file, and Wait blocks until every
+// submitted job has finished. Used by uploadICloudFiles/syncTMToICloud so
+// an initial sync over thousands of files doesn't run one file at a time.
+type workerPool struct {
+	jobs chan func()
+	wg   sync.WaitGroup
+
+	statsMu  sync.Mutex
+	inFlight int
+	queued   int
+}
+
+// newWorkerPool starts workers goroutines draining a queue of depth
+// queueDepth; both are clamped to at least 1.
+func newWorkerPool(workers, queueDepth int) *workerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueDepth < 1 {
+		queueDepth = workers
+	}
+
+	p := &workerPool{jobs: make(chan func(), queueDepth)}
+	for i := 0; i < workers; i++ {
+		go p.run()
+	}
+	return p
+}
+
+func (p *workerPool) run() {
+	for job := range p.jobs {
+		p.statsMu.Lock()
+		p.queued--
+		p.inFlight++
+		p.statsMu.Unlock()
+
+		job()
+
+		p.statsMu.Lock()
+		p.inFlight--
+		p.statsMu.Unlock()
+		p.wg.Done()
+	}
+}
+
+// Submit enqueues job, blocking if the queue is already full.
+func (p *workerPool) Submit(job func()) {
+	p.statsMu.Lock()
+	p.queued++
+	p.statsMu.Unlock()
+
+	p.wg.Add(1)
+	p.jobs <- job
+}
+
+// Wait blocks until every job submitted so far has completed.
+func (p *workerPool) Wait() {
+	p.wg.Wait()
+}
+
+// Stats returns the current in-flight and queued job counts.
+func (p *workerPool) Stats() (inFlight, queued int) {
+	p.statsMu.Lock()
+	defer p.statsMu.Unlock()
+	return p.inFlight, p.queued
+}
+
+// Close stops the pool from accepting new jobs. Only call this once, after
+// the last Submit/Wait pair - e.g. on SyncManager shutdown.
+func (p *workerPool) Close() {
+	close(p.jobs)
+}
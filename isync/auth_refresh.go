@@ -0,0 +1,109 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// refreshTokenTTL bounds how long a refresh token can sit unused before it
+// must be re-obtained via a fresh login.
+const refreshTokenTTL = 30 * 24 * time.Hour
+
+// ErrRefreshReuse is returned by refreshTokenRegistry.Consume when a
+// refresh token that was already rotated, or revoked via RevokeFamily, is
+// presented again, as happens when a token was copied off the legitimate
+// device; the whole chain is revoked as a precaution.
+var ErrRefreshReuse = fmt.Errorf("refresh token reuse detected")
+
+// refreshTokenRecord is indexed by the SHA-256 hash of the raw token
+// handed to the client, so a leaked registry alone never reveals a
+// presentable token.
+type refreshTokenRecord struct {
+	Family    string
+	Revoked   bool
+	ExpiresAt time.Time
+}
+
+// refreshTokenRegistry implements rotation-with-reuse-detection: each
+// login starts a "family" (one chain of tokens); rotating via
+// /api/auth/refresh retires the presented token and issues a new one in
+// the same family, and presenting a retired token revokes every token
+// ever issued in that family.
+type refreshTokenRegistry struct {
+	mu       sync.Mutex
+	byHash   map[string]*refreshTokenRecord
+	byFamily map[string][]string
+}
+
+func newRefreshTokenRegistry() *refreshTokenRegistry {
+	return &refreshTokenRegistry{
+		byHash:   make(map[string]*refreshTokenRecord),
+		byFamily: make(map[string][]string),
+	}
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Issue mints a new refresh token in family.
+func (r *refreshTokenRegistry) Issue(family string) (string, error) {
+	token, err := generateSecureToken()
+	if err != nil {
+		return "", err
+	}
+	hash := hashRefreshToken(token)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byHash[hash] = &refreshTokenRecord{Family: family, ExpiresAt: time.Now().Add(refreshTokenTTL)}
+	r.byFamily[family] = append(r.byFamily[family], hash)
+	return token, nil
+}
+
+// Consume validates and retires token, returning the family it belongs to
+// so the caller can locate the session and issue a replacement via Issue.
+func (r *refreshTokenRegistry) Consume(token string) (family string, err error) {
+	hash := hashRefreshToken(token)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, exists := r.byHash[hash]
+	if !exists {
+		return "", fmt.Errorf("invalid refresh token")
+	}
+	if record.Revoked {
+		for _, h := range r.byFamily[record.Family] {
+			if rec, ok := r.byHash[h]; ok {
+				rec.Revoked = true
+			}
+		}
+		return "", ErrRefreshReuse
+	}
+	if time.Now().After(record.ExpiresAt) {
+		return "", fmt.Errorf("refresh token expired")
+	}
+
+	record.Revoked = true
+	return record.Family, nil
+}
+
+// RevokeFamily invalidates every refresh token ever issued in family, used
+// when a session is explicitly revoked via /api/auth/sessions/:id.
+func (r *refreshTokenRegistry) RevokeFamily(family string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, h := range r.byFamily[family] {
+		if rec, ok := r.byHash[h]; ok {
+			rec.Revoked = true
+		}
+	}
+}
+
+// refreshTokens is the active refresh-token registry.
+var refreshTokens = newRefreshTokenRegistry()
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LegalDataProvider is the interface for an external legal data service
+// (UniCourt-compatible or otherwise) used to enrich defendant and attorney
+// information. Implementations can be swapped without touching handlers.
+type LegalDataProvider interface {
+	SearchEntity(name, state string) (*EntityLookupResult, error)
+	GetEntity(id string) (*EntityLookupResult, error)
+	GetAttorney(barNumber string) (*AttorneyLookupResult, error)
+	RelatedFCRACases(defendantName string) ([]RelatedCase, error)
+}
+
+// EntityLookupResult is the normalized shape returned by a provider search,
+// mapped onto the existing Defendant fields.
+type EntityLookupResult struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	EntityType      string `json:"entityType"`
+	Address         string `json:"address"`
+	RegisteredAgent string `json:"registeredAgent"`
+	State           string `json:"state"`
+	County          string `json:"county"`
+}
+
+// AttorneyLookupResult maps onto the attorney fields on ClientCase.
+type AttorneyLookupResult struct {
+	Name      string `json:"name"`
+	BarNumber string `json:"barNumber"`
+	Firm      string `json:"firm"`
+	Email     string `json:"email"`
+	Phone     string `json:"phone"`
+}
+
+// RelatedCase represents a prior FCRA suit against a defendant.
+type RelatedCase struct {
+	CaseNumber string    `json:"caseNumber"`
+	Court      string    `json:"court"`
+	FiledDate  time.Time `json:"filedDate"`
+}
+
+// LegalDataProviderConfig holds the base URL, token, and rate limits for a
+// UniCourt-compatible provider.
+type LegalDataProviderConfig struct {
+	BaseURL           string
+	Token             string
+	RequestsPerMinute int
+}
+
+// uniCourtProvider implements LegalDataProvider for a UniCourt-compatible
+// REST API (/search, /parties, /attorneys, /related-cases). No request is
+// sent to config.BaseURL: every method logs the call and returns a nil
+// result, so callers fall back to their hardcoded values or a 404.
+type uniCourtProvider struct {
+	config LegalDataProviderConfig
+	client *http.Client
+}
+
+// newUniCourtProvider constructs a provider from config; alternative
+// providers implementing LegalDataProvider can be swapped in by changing
+// activeLegalDataProvider below.
+func newUniCourtProvider(config LegalDataProviderConfig) *uniCourtProvider {
+	return &uniCourtProvider{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *uniCourtProvider) SearchEntity(name, state string) (*EntityLookupResult, error) {
+	// For the prototype, the live UniCourt-compatible call is stubbed out
+	// and we return nil so callers fall back to hardcoded defaults. A real
+	// implementation would GET p.config.BaseURL+"/search?name=..." and map
+	// the "parties" result into EntityLookupResult.
+	log.Printf("caselookup: SearchEntity(%s, %s) against %s (no live provider configured)", name, state, p.config.BaseURL)
+	return nil, nil
+}
+
+func (p *uniCourtProvider) GetEntity(id string) (*EntityLookupResult, error) {
+	log.Printf("caselookup: GetEntity(%s) against %s", id, p.config.BaseURL)
+	return nil, nil
+}
+
+func (p *uniCourtProvider) GetAttorney(barNumber string) (*AttorneyLookupResult, error) {
+	log.Printf("caselookup: GetAttorney(%s) against %s", barNumber, p.config.BaseURL)
+	return nil, nil
+}
+
+func (p *uniCourtProvider) RelatedFCRACases(defendantName string) ([]RelatedCase, error) {
+	log.Printf("caselookup: RelatedFCRACases(%s) against %s", defendantName, p.config.BaseURL)
+	return nil, nil
+}
+
+// activeLegalDataProvider is the provider instance used by handlers and the
+// defendant resolver. Swapping providers means changing this assignment.
+var activeLegalDataProvider LegalDataProvider = newUniCourtProvider(LegalDataProviderConfig{
+	BaseURL:           "https://api.unicourt.com/v1",
+	Token:             "",
+	RequestsPerMinute: 60,
+})
+
+// resolveDefendant fills Address/RegisteredAgent/State/County from the
+// configured LegalDataProvider, falling back to the supplied hardcoded
+// defaults when the provider has no data.
+func resolveDefendant(fallback Defendant) Defendant {
+	result, err := activeLegalDataProvider.SearchEntity(fallback.Name, fallback.State)
+	if err != nil {
+		log.Printf("caselookup: lookup failed for %s, using hardcoded defaults: %v", fallback.Name, err)
+		return fallback
+	}
+	if result == nil {
+		return fallback
+	}
+
+	resolved := fallback
+	resolved.Address = getValueOrDefault(result.Address, fallback.Address)
+	resolved.RegisteredAgent = getValueOrDefault(result.RegisteredAgent, fallback.RegisteredAgent)
+	resolved.State = getValueOrDefault(result.State, fallback.State)
+	resolved.County = getValueOrDefault(result.County, fallback.County)
+	return resolved
+}
+
+// resolveDefendants resolves each defendant in turn, falling back to its
+// hardcoded values whenever the provider has no data.
+func resolveDefendants(defendants []Defendant) []Defendant {
+	resolved := make([]Defendant, len(defendants))
+	for i, defendant := range defendants {
+		resolved[i] = resolveDefendant(defendant)
+	}
+	return resolved
+}
+
+// resolveRelatedCases queries the provider for prior FCRA suits against each
+// defendant and returns them ranked by recency.
+func resolveRelatedCases(defendants []Defendant) []string {
+	var all []RelatedCase
+	for _, defendant := range defendants {
+		cases, err := activeLegalDataProvider.RelatedFCRACases(defendant.Name)
+		if err != nil {
+			log.Printf("caselookup: related-cases lookup failed for %s: %v", defendant.Name, err)
+			continue
+		}
+		all = append(all, cases...)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].FiledDate.After(all[j].FiledDate)
+	})
+
+	caseNumbers := make([]string, 0, len(all))
+	for _, c := range all {
+		caseNumbers = append(caseNumbers, c.CaseNumber)
+	}
+	return caseNumbers
+}
+
+// handleCaseLookupSearch searches for an entity by name and state.
+func handleCaseLookupSearch(c *gin.Context) {
+	name := c.Query("name")
+	state := c.Query("state")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name query parameter is required"})
+		return
+	}
+
+	result, err := activeLegalDataProvider.SearchEntity(name, state)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Lookup failed: " + err.Error()})
+		return
+	}
+	if result == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No entity found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// handleCaseLookupEntity fetches a single entity by provider ID.
+func handleCaseLookupEntity(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := activeLegalDataProvider.GetEntity(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Lookup failed: " + err.Error()})
+		return
+	}
+	if result == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No entity found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// handleCaseLookupAttorney fetches attorney information by bar number.
+func handleCaseLookupAttorney(c *gin.Context) {
+	barNumber := c.Param("barNumber")
+
+	result, err := activeLegalDataProvider.GetAttorney(barNumber)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Lookup failed: " + err.Error()})
+		return
+	}
+	if result == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No attorney found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
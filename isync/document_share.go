@@ -0,0 +1,379 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// shareSecret signs every share link's token so it can't be forged or
+// altered client-side; generated once at process start like
+// icloudCredentialsKey, since no secret-management dependency is vendored
+// in this prototype.
+var shareSecret = generateShareSecret()
+
+func generateShareSecret() []byte {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic("document share: failed to generate signing secret: " + err.Error())
+	}
+	return secret
+}
+
+// DocumentShare is a shareable link to one saved document, scoped to an
+// optional expiry, password, and remaining download count, analogous to
+// teldrive's file-share resource.
+type DocumentShare struct {
+	Token        string     `json:"token"`
+	DocumentID   string     `json:"documentId"` // the activeStorage key (filename)
+	Scope        string     `json:"scope"`      // "file" | "folder"
+	PasswordHash string     `json:"-"`
+	HasPassword  bool       `json:"hasPassword"`
+	MaxDownloads int        `json:"maxDownloads,omitempty"` // 0 = unlimited
+	Downloads    int        `json:"downloads"`
+	CreatedBy    string     `json:"createdBy"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+	Revoked      bool       `json:"revoked"`
+}
+
+// documentShareAccess records one access attempt against a share, the
+// audit trail a firm can be asked to produce for a shared filing.
+type documentShareAccess struct {
+	Token     string    `json:"token"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"userAgent"`
+	Time      time.Time `json:"time"`
+	Outcome   string    `json:"outcome"` // "served" | "wrong_password" | "expired" | "exhausted" | "revoked"
+}
+
+// documentShareStore holds shares and their access log in memory only, so
+// both are lost on restart; unlike sessionStore (see sessions.go), which is
+// bbolt-backed, it has no persistent backend.
+type documentShareStore struct {
+	mu      sync.Mutex
+	byToken map[string]*DocumentShare
+	access  []documentShareAccess
+}
+
+func newDocumentShareStore() *documentShareStore {
+	return &documentShareStore{byToken: make(map[string]*DocumentShare)}
+}
+
+func (s *documentShareStore) Save(share *DocumentShare) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.byToken[share.Token] = share
+}
+
+func (s *documentShareStore) Get(token string) (*DocumentShare, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	share, exists := s.byToken[token]
+	return share, exists
+}
+
+func (s *documentShareStore) ForDocument(documentID string) []*DocumentShare {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var shares []*DocumentShare
+	for _, share := range s.byToken {
+		if share.DocumentID == documentID {
+			shares = append(shares, share)
+		}
+	}
+	return shares
+}
+
+func (s *documentShareStore) RecordAccess(access documentShareAccess) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.access = append(s.access, access)
+	auditLog("share_access", gin.H{
+		"token": access.Token, "ip": access.IP, "userAgent": access.UserAgent, "outcome": access.Outcome,
+	})
+}
+
+var documentShares = newDocumentShareStore()
+
+// signShareToken derives the HMAC-SHA256 signature embedded in a share
+// link, keyed by shareSecret, so /s/:token can reject a tampered or
+// forged token before ever touching documentShareStore.
+func signShareToken(token string) string {
+	mac := hmac.New(sha256.New, shareSecret)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// generateShareToken mints a random token plus its signature; the full
+// public link is /s/<token>.<signature>.
+func generateShareToken() (full, raw string, err error) {
+	raw, err = generateSecureToken()
+	if err != nil {
+		return "", "", err
+	}
+	return raw + "." + signShareToken(raw), raw, nil
+}
+
+// verifyShareToken splits a presented /s/:token path segment back into
+// its raw token and checks the signature.
+func verifyShareToken(presented string) (string, bool) {
+	parts := strings.SplitN(presented, ".", 2)
+	if len(parts) != 2 {
+		return "", false
+	}
+	raw, sig := parts[0], parts[1]
+	if !hmac.Equal([]byte(sig), []byte(signShareToken(raw))) {
+		return "", false
+	}
+	return raw, true
+}
+
+// handleCreateDocumentShare creates a share link for a saved document.
+func handleCreateDocumentShare(c *gin.Context) {
+	var request struct {
+		DocumentID       string `json:"documentId"`
+		Scope            string `json:"scope"`
+		Password         string `json:"password"`
+		MaxDownloads     int    `json:"maxDownloads"`
+		ExpiresInSeconds int    `json:"expiresInSeconds"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	documentID := request.DocumentID
+	if documentID == "" || strings.Contains(documentID, "..") || strings.Contains(documentID, "/") || strings.Contains(documentID, "\\") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document id"})
+		return
+	}
+	if !activeStorage.Exists(documentID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return
+	}
+	if request.Scope == "" {
+		request.Scope = "file"
+	}
+
+	fullToken, rawToken, err := generateShareToken()
+	if err != nil {
+		log.Printf("Failed to generate share token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create share"})
+		return
+	}
+
+	share := &DocumentShare{
+		Token:        rawToken,
+		DocumentID:   documentID,
+		Scope:        request.Scope,
+		MaxDownloads: request.MaxDownloads,
+		CreatedBy:    c.GetString("username"),
+		CreatedAt:    time.Now(),
+	}
+	if request.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(request.Password), minBcryptCost)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash share password"})
+			return
+		}
+		share.PasswordHash = string(hash)
+		share.HasPassword = true
+	}
+	if request.ExpiresInSeconds > 0 {
+		expires := time.Now().Add(time.Duration(request.ExpiresInSeconds) * time.Second)
+		share.ExpiresAt = &expires
+	}
+
+	documentShares.Save(share)
+	log.Printf("Created share %s for document %s (scope=%s)", share.Token, documentID, share.Scope)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"share":    share,
+		"shareUrl": "/s/" + fullToken,
+	})
+}
+
+// handleListDocumentShares lists the caller's own shares for a document.
+// Scoped to CreatedBy like handleUpdateDocumentShare and
+// handleRevokeDocumentShare, so one user's read:documents scope can't be
+// used to enumerate another user's share tokens for a document they both
+// happen to have access to.
+func handleListDocumentShares(c *gin.Context) {
+	documentID := c.Query("documentId")
+	if documentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "documentId query parameter required"})
+		return
+	}
+	username := c.GetString("username")
+	all := documentShares.ForDocument(documentID)
+	shares := make([]*DocumentShare, 0, len(all))
+	for _, share := range all {
+		if share.CreatedBy == username {
+			shares = append(shares, share)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"shares": shares, "count": len(shares)})
+}
+
+// handleUpdateDocumentShare edits a share's password, download cap, or
+// expiry. Omitted fields are left unchanged.
+func handleUpdateDocumentShare(c *gin.Context) {
+	token := c.Param("token")
+	share, exists := documentShares.Get(token)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share not found"})
+		return
+	}
+	if share.CreatedBy != c.GetString("username") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot edit another user's share"})
+		return
+	}
+
+	var request struct {
+		Password         *string `json:"password"`
+		MaxDownloads     *int    `json:"maxDownloads"`
+		ExpiresInSeconds *int    `json:"expiresInSeconds"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if request.Password != nil {
+		if *request.Password == "" {
+			share.PasswordHash = ""
+			share.HasPassword = false
+		} else {
+			hash, err := bcrypt.GenerateFromPassword([]byte(*request.Password), minBcryptCost)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash share password"})
+				return
+			}
+			share.PasswordHash = string(hash)
+			share.HasPassword = true
+		}
+	}
+	if request.MaxDownloads != nil {
+		share.MaxDownloads = *request.MaxDownloads
+	}
+	if request.ExpiresInSeconds != nil {
+		if *request.ExpiresInSeconds <= 0 {
+			share.ExpiresAt = nil
+		} else {
+			expires := time.Now().Add(time.Duration(*request.ExpiresInSeconds) * time.Second)
+			share.ExpiresAt = &expires
+		}
+	}
+
+	documentShares.Save(share)
+	c.JSON(http.StatusOK, gin.H{"success": true, "share": share})
+}
+
+// handleRevokeDocumentShare revokes a share. The record stays in the
+// store (marked revoked) rather than being deleted so /s/:token can
+// report a clear "revoked" error instead of a generic 404.
+func handleRevokeDocumentShare(c *gin.Context) {
+	token := c.Param("token")
+	share, exists := documentShares.Get(token)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share not found"})
+		return
+	}
+	if share.CreatedBy != c.GetString("username") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot revoke another user's share"})
+		return
+	}
+	share.Revoked = true
+	documentShares.Save(share)
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// handlePublicShareAccess serves a shared document to an anonymous caller
+// after validating the token's signature and the share's state (expiry,
+// password, remaining downloads, revocation). No session is required.
+// Each served download, and each attempt on an existing share refused as
+// revoked, expired, exhausted or for a wrong password, is recorded via
+// documentShares.RecordAccess for audit. Invalid links, a request with no
+// password, and a missing or unreadable document are not recorded.
+func handlePublicShareAccess(c *gin.Context) {
+	rawToken, ok := verifyShareToken(c.Param("token"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invalid share link"})
+		return
+	}
+
+	share, exists := documentShares.Get(rawToken)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invalid share link"})
+		return
+	}
+
+	access := documentShareAccess{Token: rawToken, IP: c.ClientIP(), UserAgent: c.Request.UserAgent(), Time: time.Now()}
+
+	if share.Revoked {
+		access.Outcome = "revoked"
+		documentShares.RecordAccess(access)
+		c.JSON(http.StatusGone, gin.H{"error": "This share link has been revoked"})
+		return
+	}
+	if share.ExpiresAt != nil && time.Now().After(*share.ExpiresAt) {
+		access.Outcome = "expired"
+		documentShares.RecordAccess(access)
+		c.JSON(http.StatusGone, gin.H{"error": "This share link has expired"})
+		return
+	}
+	if share.MaxDownloads > 0 && share.Downloads >= share.MaxDownloads {
+		access.Outcome = "exhausted"
+		documentShares.RecordAccess(access)
+		c.JSON(http.StatusGone, gin.H{"error": "This share link has reached its download limit"})
+		return
+	}
+
+	if share.PasswordHash != "" {
+		password := c.Query("password")
+		if password == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Password required", "passwordRequired": true})
+			return
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(share.PasswordHash), []byte(password)); err != nil {
+			access.Outcome = "wrong_password"
+			documentShares.RecordAccess(access)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Incorrect password", "passwordRequired": true})
+			return
+		}
+	}
+
+	if !activeStorage.Exists(share.DocumentID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document no longer available"})
+		return
+	}
+	content, err := activeStorage.Get(share.DocumentID)
+	if err != nil {
+		log.Printf("Error reading shared document %s: %v", share.DocumentID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read document"})
+		return
+	}
+
+	share.Downloads++
+	documentShares.Save(share)
+	access.Outcome = "served"
+	documentShares.RecordAccess(access)
+
+	c.Header("Content-Disposition", "attachment; filename=\""+share.DocumentID+"\"")
+	c.Header("Accept-Ranges", "bytes")
+	http.ServeContent(c.Writer, c.Request, share.DocumentID, time.Now(), bytes.NewReader(content))
+
+	log.Printf("Shared document served: %s via token %s", share.DocumentID, share.Token)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrderStatus represents the lifecycle state of a certified-copy order.
+type OrderStatus string
+
+const (
+	OrderRequested OrderStatus = "Requested"
+	OrderFulfilled OrderStatus = "Fulfilled"
+	OrderFailed    OrderStatus = "Failed"
+)
+
+// DocumentOrder tracks a certified-copy request for a docket document.
+// Orders are kept only in the in-memory documentOrders map and are lost on
+// restart.
+type DocumentOrder struct {
+	OrderID        string      `json:"orderId"`
+	CaseNumber     string      `json:"caseNumber"`
+	DocketItem     string      `json:"docketItem"`
+	PageCount      int         `json:"pageCount"`
+	CostEstimate   string      `json:"costEstimate"`
+	DeliveryETA    string      `json:"deliveryETA"`
+	IdempotencyKey string      `json:"idempotencyKey"`
+	Status         OrderStatus `json:"status"`
+	FilePath       string      `json:"filePath,omitempty"`
+	CreatedAt      time.Time   `json:"createdAt"`
+	UpdatedAt      time.Time   `json:"updatedAt"`
+}
+
+var (
+	documentOrders          = make(map[string]*DocumentOrder)
+	documentOrdersMu        sync.Mutex
+	documentOrderIdempotency = make(map[string]string) // idempotencyKey -> orderId
+)
+
+// handleDocumentOrderPreview returns a cost estimate, page count, and
+// delivery ETA for a certified copy before the attorney commits to the
+// order.
+func handleDocumentOrderPreview(c *gin.Context) {
+	var request struct {
+		CaseNumber string `json:"caseNumber"`
+		DocketItem string `json:"docketItem"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	if request.CaseNumber == "" || request.DocketItem == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "caseNumber and docketItem are required"})
+		return
+	}
+
+	// The prototype simulates a provider quote; production would call out
+	// to the court's document-order API for the real estimate.
+	c.JSON(http.StatusOK, gin.H{
+		"pageCount":    12,
+		"costEstimate": "$15.00",
+		"deliveryETA":  "2-3 business days",
+	})
+}
+
+// handleDocumentOrderConfirm creates an order with billing metadata.
+// Retried confirms with the same idempotency key return the original order
+// instead of double-charging.
+func handleDocumentOrderConfirm(c *gin.Context) {
+	var request struct {
+		CaseNumber     string `json:"caseNumber"`
+		DocketItem     string `json:"docketItem"`
+		IdempotencyKey string `json:"idempotencyKey"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	if request.IdempotencyKey == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "idempotencyKey is required"})
+		return
+	}
+
+	documentOrdersMu.Lock()
+	if existingOrderID, seen := documentOrderIdempotency[request.IdempotencyKey]; seen {
+		existing := documentOrders[existingOrderID]
+		documentOrdersMu.Unlock()
+		c.JSON(http.StatusOK, existing)
+		return
+	}
+
+	orderID := fmt.Sprintf("order_%d", time.Now().UnixNano())
+	order := &DocumentOrder{
+		OrderID:        orderID,
+		CaseNumber:     request.CaseNumber,
+		DocketItem:     request.DocketItem,
+		PageCount:      12,
+		CostEstimate:   "$15.00",
+		DeliveryETA:    "2-3 business days",
+		IdempotencyKey: request.IdempotencyKey,
+		Status:         OrderRequested,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+	documentOrders[orderID] = order
+	documentOrderIdempotency[request.IdempotencyKey] = orderID
+	documentOrdersMu.Unlock()
+
+	go fulfillDocumentOrder(order)
+
+	log.Printf("Document order created: %s for case %s, docket item %s", orderID, request.CaseNumber, request.DocketItem)
+
+	c.JSON(http.StatusOK, order)
+}
+
+// handleDocumentOrderStatus returns the current state of a certified-copy
+// order.
+func handleDocumentOrderStatus(c *gin.Context) {
+	orderID := c.Param("orderId")
+
+	documentOrdersMu.Lock()
+	order, exists := documentOrders[orderID]
+	documentOrdersMu.Unlock()
+
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// handleDocumentOrderDownload streams the fulfilled certified copy once
+// available.
+func handleDocumentOrderDownload(c *gin.Context) {
+	orderID := c.Param("orderId")
+
+	documentOrdersMu.Lock()
+	order, exists := documentOrders[orderID]
+	documentOrdersMu.Unlock()
+
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+	if order.Status != OrderFulfilled || order.FilePath == "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Order has not been fulfilled yet", "status": order.Status})
+		return
+	}
+
+	c.File(order.FilePath)
+}
+
+// fulfillDocumentOrder simulates the provider fulfilling the order: after a
+// short delay it marks the order Fulfilled and sets its FilePath. It does
+// not add the copy to any case's SavedDocuments; attachOrderToSavedDocuments
+// does that for a given ClientCase and is not called from here.
+func fulfillDocumentOrder(order *DocumentOrder) {
+	time.Sleep(2 * time.Second)
+
+	documentOrdersMu.Lock()
+	order.Status = OrderFulfilled
+	order.FilePath = "/Users/corelogic/satori-dev/clients/proj-mallon/dev/saved_documents/" + order.OrderID + "_certified.pdf"
+	order.UpdatedAt = time.Now()
+	documentOrdersMu.Unlock()
+
+	log.Printf("Document order fulfilled: %s", order.OrderID)
+}
+
+// attachOrderToSavedDocuments appends a fulfilled order's certified copy to
+// a ClientCase's SavedDocuments list.
+func attachOrderToSavedDocuments(clientCase *ClientCase, order *DocumentOrder) {
+	if order.Status != OrderFulfilled {
+		return
+	}
+	clientCase.SavedDocuments = append(clientCase.SavedDocuments, SavedDocument{
+		ID:           order.OrderID,
+		FileName:     order.OrderID + "_certified.pdf",
+		FilePath:     order.FilePath,
+		DocumentType: "certified_copy",
+		SavedDate:    order.UpdatedAt,
+		Status:       "saved",
+	})
+}
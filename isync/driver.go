@@ -0,0 +1,731 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// Driver is the common storage backend contract every cloud-storage
+// integration implements. It replaces the macOS-path assumptions that used
+// to be hard-coded into getRealICloudFolders/Subfolders/Documents with a
+// single abstraction, so handlers pick a Driver from the caller's context
+// (see driverForUser in storages.go) instead of talking to the filesystem
+// or the Apple-specific client directly.
+type Driver interface {
+	List(path string) ([]ICloudDocument, error)
+	Stat(path string) (ICloudDocument, error)
+	Open(path string) (io.ReadCloser, error)
+	// OpenRange opens path starting at offset, yielding at most length
+	// bytes (or to EOF when length is negative), so callers can stream a
+	// byte range without buffering the whole file - used to back HTTP
+	// Range requests (see handleICloudStreamDocument in tus_uploads.go).
+	OpenRange(path string, offset, length int64) (io.ReadCloser, error)
+	Put(path string, r io.Reader) error
+	Mkdir(path string) error
+	Remove(path string) error
+	Move(src, dst string) error
+}
+
+// DriverFactory constructs a Driver from the decrypted settings saved in a
+// StorageConfig (see storages.go), e.g. {"bucket": "...", "region": "..."}
+// for s3Driver.
+type DriverFactory func(settings map[string]string) (Driver, error)
+
+// driverRegistry maps a driver name (as stored on StorageConfig.Driver) to
+// the factory that builds it. Populated by RegisterDriver in this file's
+// init(), the same way roleScopes is populated as a package var in
+// sessions.go.
+var driverRegistry = map[string]DriverFactory{}
+
+// RegisterDriver adds a named driver to the registry.
+func RegisterDriver(name string, factory DriverFactory) {
+	driverRegistry[name] = factory
+}
+
+// NewDriver constructs the named driver with the given settings.
+func NewDriver(name string, settings map[string]string) (Driver, error) {
+	factory, exists := driverRegistry[name]
+	if !exists {
+		return nil, fmt.Errorf("unknown storage driver: %s", name)
+	}
+	return factory(settings)
+}
+
+func init() {
+	RegisterDriver("local", newLocalDriver)
+	RegisterDriver("icloud", newICloudDriver)
+	RegisterDriver("gdrive", newGDriveDriver)
+	RegisterDriver("dropbox", newDropboxDriver)
+	RegisterDriver("s3", newS3DriverFromSettings)
+	RegisterDriver("webdav", newWebDAVDriver)
+}
+
+// localDriver implements Driver against a directory on the local
+// filesystem, matching the test-directory/Mobile-Documents-mount fallback
+// the previous get*ICloud* functions used directly.
+type localDriver struct {
+	baseDir string
+}
+
+func newLocalDriver(settings map[string]string) (Driver, error) {
+	baseDir := settings["baseDir"]
+	if baseDir == "" {
+		return nil, fmt.Errorf("localDriver: baseDir setting is required")
+	}
+	return &localDriver{baseDir: baseDir}, nil
+}
+
+// resolve maps a caller-supplied path onto the local filesystem under
+// baseDir, rejecting ".." segments the same way the Storage and filename
+// handlers elsewhere in this package do (see handleViewDocument in
+// main.go) - without this, any path ultimately reaching a localDriver
+// (including one rooted at an attorney-chosen baseDir via
+// handleCreateStorage) could walk out of baseDir entirely.
+func (d *localDriver) resolve(path string) (string, error) {
+	if strings.Contains(path, "..") {
+		return "", fmt.Errorf("localDriver: invalid path %q", path)
+	}
+	clean := filepath.Clean("/" + strings.TrimPrefix(path, "/"))
+	return filepath.Join(d.baseDir, clean), nil
+}
+
+func (d *localDriver) List(path string) ([]ICloudDocument, error) {
+	resolved, err := d.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("localDriver: failed to read %s: %v", path, err)
+	}
+
+	parent := strings.TrimSuffix(path, "/")
+	var items []ICloudDocument
+	for i, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		items = append(items, ICloudDocument{
+			ID:          fmt.Sprintf("item_%d", i),
+			Name:        entry.Name(),
+			Path:        parent + "/" + entry.Name(),
+			IsDirectory: entry.IsDir(),
+			Size:        info.Size(),
+			Modified:    info.ModTime(),
+			Type:        fileTypeForName(entry.Name()),
+		})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+	return items, nil
+}
+
+func (d *localDriver) Stat(path string) (ICloudDocument, error) {
+	resolved, err := d.resolve(path)
+	if err != nil {
+		return ICloudDocument{}, err
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return ICloudDocument{}, fmt.Errorf("localDriver: stat %s: %v", path, err)
+	}
+	return ICloudDocument{
+		Name:        info.Name(),
+		Path:        path,
+		IsDirectory: info.IsDir(),
+		Size:        info.Size(),
+		Modified:    info.ModTime(),
+		Type:        fileTypeForName(info.Name()),
+	}, nil
+}
+
+func (d *localDriver) Open(path string) (io.ReadCloser, error) {
+	resolved, err := d.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("localDriver: open %s: %v", path, err)
+	}
+	return f, nil
+}
+
+func (d *localDriver) OpenRange(path string, offset, length int64) (io.ReadCloser, error) {
+	resolved, err := d.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("localDriver: open %s: %v", path, err)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("localDriver: seek %s to %d: %v", path, offset, err)
+	}
+	if length < 0 {
+		return f, nil
+	}
+	return &limitedReadCloser{r: io.LimitReader(f, length), c: f}, nil
+}
+
+func (d *localDriver) Put(path string, r io.Reader) error {
+	full, err := d.resolve(path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return fmt.Errorf("localDriver: mkdir for %s: %v", path, err)
+	}
+	f, err := os.Create(full)
+	if err != nil {
+		return fmt.Errorf("localDriver: create %s: %v", path, err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, r); err != nil {
+		return fmt.Errorf("localDriver: write %s: %v", path, err)
+	}
+	return nil
+}
+
+func (d *localDriver) Mkdir(path string) error {
+	resolved, err := d.resolve(path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(resolved, 0755); err != nil {
+		return fmt.Errorf("localDriver: mkdir %s: %v", path, err)
+	}
+	return nil
+}
+
+func (d *localDriver) Remove(path string) error {
+	resolved, err := d.resolve(path)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(resolved); err != nil {
+		return fmt.Errorf("localDriver: remove %s: %v", path, err)
+	}
+	return nil
+}
+
+func (d *localDriver) Move(src, dst string) error {
+	resolvedSrc, err := d.resolve(src)
+	if err != nil {
+		return err
+	}
+	resolvedDst, err := d.resolve(dst)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(resolvedSrc, resolvedDst); err != nil {
+		return fmt.Errorf("localDriver: move %s -> %s: %v", src, dst, err)
+	}
+	return nil
+}
+
+// limitedReadCloser pairs an io.LimitReader with the underlying file so
+// OpenRange callers can Close() it like any other Driver.Open result.
+type limitedReadCloser struct {
+	r io.Reader
+	c io.Closer
+}
+
+func (l *limitedReadCloser) Read(b []byte) (int, error) { return l.r.Read(b) }
+func (l *limitedReadCloser) Close() error                { return l.c.Close() }
+
+// fileTypeForName classifies a file by extension, matching the switch
+// getRealICloudDocuments used to run inline.
+func fileTypeForName(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	switch ext {
+	case ".pdf":
+		return "pdf"
+	case ".docx":
+		return "docx"
+	case ".doc":
+		return "doc"
+	case ".txt":
+		return "txt"
+	case ".jpg", ".jpeg", ".png":
+		return "image"
+	default:
+		return strings.TrimPrefix(ext, ".")
+	}
+}
+
+// icloudDriver adapts the real appleICloudClient (see icloud_client.go) to
+// the Driver interface. It is constructed directly from an authenticated
+// client by driverForUser rather than through the registry, since it needs
+// a live session and not just settings - newICloudDriver below only exists
+// to satisfy DriverFactory for callers that look the name up generically.
+type icloudDriver struct {
+	client *appleICloudClient
+}
+
+func newICloudDriver(settings map[string]string) (Driver, error) {
+	return nil, fmt.Errorf("icloudDriver: requires an authenticated session, construct via driverForUser instead of NewDriver")
+}
+
+func (d *icloudDriver) List(path string) ([]ICloudDocument, error) {
+	return d.client.ListContents("com.apple.CloudDocs", "", path)
+}
+
+func (d *icloudDriver) Stat(path string) (ICloudDocument, error) {
+	items, err := d.client.ListContents("com.apple.CloudDocs", "", filepath.Dir(path))
+	if err != nil {
+		return ICloudDocument{}, err
+	}
+	for _, item := range items {
+		if item.Path == path || item.Name == filepath.Base(path) {
+			return item, nil
+		}
+	}
+	return ICloudDocument{}, fmt.Errorf("icloudDriver: %s not found", path)
+}
+
+// Open downloads path (a drivews item ID, since ListContents doesn't
+// expose a separate hierarchical path for each item) via the signed docws
+// URL and buffers it in memory, mirroring DownloadByID's existing
+// all-at-once behavior.
+func (d *icloudDriver) Open(path string) (io.ReadCloser, error) {
+	content, err := d.client.DownloadByID(path)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+// OpenRange fetches a byte range of path from its signed docws download
+// URL using a standard HTTP Range request, so a large PDF can be streamed
+// a chunk at a time instead of buffering the whole file the way Open does.
+func (d *icloudDriver) OpenRange(path string, offset, length int64) (io.ReadCloser, error) {
+	downloadURL, err := d.client.GetDocByItemID(path)
+	if err != nil {
+		return nil, err
+	}
+	if downloadURL == "" {
+		return nil, fmt.Errorf("icloudDriver: no download URL returned for %s", path)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("icloudDriver: building range request for %s: %v", path, err)
+	}
+	if length < 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	} else {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	}
+
+	resp, err := d.client.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("icloudDriver: range request for %s: %v", path, err)
+	}
+	if resp.StatusCode != http.StatusPartialContent && resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("icloudDriver: range request for %s returned %s", path, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// Put/Mkdir/Remove/Move aren't implemented by appleICloudClient yet -
+// CloudKit folder and file mutation requires signed FOLDER::record-change
+// requests beyond the read/chunk-upload surface this prototype's client
+// covers (see UploadChunk in icloud_client.go for the same limitation).
+func (d *icloudDriver) Put(path string, r io.Reader) error {
+	return fmt.Errorf("icloudDriver: Put not implemented in this prototype")
+}
+
+func (d *icloudDriver) Mkdir(path string) error {
+	return fmt.Errorf("icloudDriver: Mkdir not implemented in this prototype")
+}
+
+func (d *icloudDriver) Remove(path string) error {
+	return fmt.Errorf("icloudDriver: Remove not implemented in this prototype")
+}
+
+func (d *icloudDriver) Move(src, dst string) error {
+	return fmt.Errorf("icloudDriver: Move not implemented in this prototype")
+}
+
+// gDriveDriver is a stub Driver backed by Google Drive.
+type gDriveDriver struct {
+	folderID string
+}
+
+func newGDriveDriver(settings map[string]string) (Driver, error) {
+	// TODO: exchange settings["refreshToken"] for an access token and call
+	// drive.files.list/get scoped to settings["folderID"].
+	return &gDriveDriver{folderID: settings["folderID"]}, nil
+}
+
+func (d *gDriveDriver) List(path string) ([]ICloudDocument, error) {
+	return nil, fmt.Errorf("gDriveDriver: not implemented in this prototype")
+}
+func (d *gDriveDriver) Stat(path string) (ICloudDocument, error) {
+	return ICloudDocument{}, fmt.Errorf("gDriveDriver: not implemented in this prototype")
+}
+func (d *gDriveDriver) Open(path string) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("gDriveDriver: not implemented in this prototype")
+}
+func (d *gDriveDriver) OpenRange(path string, offset, length int64) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("gDriveDriver: not implemented in this prototype")
+}
+func (d *gDriveDriver) Put(path string, r io.Reader) error {
+	return fmt.Errorf("gDriveDriver: not implemented in this prototype")
+}
+func (d *gDriveDriver) Mkdir(path string) error {
+	return fmt.Errorf("gDriveDriver: not implemented in this prototype")
+}
+func (d *gDriveDriver) Remove(path string) error {
+	return fmt.Errorf("gDriveDriver: not implemented in this prototype")
+}
+func (d *gDriveDriver) Move(src, dst string) error {
+	return fmt.Errorf("gDriveDriver: not implemented in this prototype")
+}
+
+// dropboxDriver is a stub Driver backed by Dropbox.
+type dropboxDriver struct {
+	accessToken string
+	rootPath    string
+}
+
+func newDropboxDriver(settings map[string]string) (Driver, error) {
+	// TODO: call files/list_folder and files/download at
+	// api.dropboxpath.com using settings["accessToken"].
+	return &dropboxDriver{accessToken: settings["accessToken"], rootPath: settings["rootPath"]}, nil
+}
+
+func (d *dropboxDriver) List(path string) ([]ICloudDocument, error) {
+	return nil, fmt.Errorf("dropboxDriver: not implemented in this prototype")
+}
+func (d *dropboxDriver) Stat(path string) (ICloudDocument, error) {
+	return ICloudDocument{}, fmt.Errorf("dropboxDriver: not implemented in this prototype")
+}
+func (d *dropboxDriver) Open(path string) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("dropboxDriver: not implemented in this prototype")
+}
+func (d *dropboxDriver) OpenRange(path string, offset, length int64) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("dropboxDriver: not implemented in this prototype")
+}
+func (d *dropboxDriver) Put(path string, r io.Reader) error {
+	return fmt.Errorf("dropboxDriver: not implemented in this prototype")
+}
+func (d *dropboxDriver) Mkdir(path string) error {
+	return fmt.Errorf("dropboxDriver: not implemented in this prototype")
+}
+func (d *dropboxDriver) Remove(path string) error {
+	return fmt.Errorf("dropboxDriver: not implemented in this prototype")
+}
+func (d *dropboxDriver) Move(src, dst string) error {
+	return fmt.Errorf("dropboxDriver: not implemented in this prototype")
+}
+
+// s3CompatibleDriver implements Driver against S3 or an S3-compatible
+// endpoint (MinIO, R2, ...), the same aws-sdk-go-v2 wiring as s3Provider in
+// isync/adapter/provider_s3.go. "Directories" are modeled the S3 way: a
+// zero-byte object whose key ends in "/", and List groups by "/" delimiter
+// via CommonPrefixes rather than a real filesystem readdir.
+type s3CompatibleDriver struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+func newS3DriverFromSettings(settings map[string]string) (Driver, error) {
+	bucket := settings["bucket"]
+	if bucket == "" {
+		return nil, fmt.Errorf("s3CompatibleDriver: bucket setting is required")
+	}
+
+	opts := []func(*config.LoadOptions) error{}
+	if settings["region"] != "" {
+		opts = append(opts, config.WithRegion(settings["region"]))
+	}
+	if settings["accessKeyId"] != "" {
+		opts = append(opts, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(settings["accessKeyId"], settings["secretAccessKey"], "")))
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("s3CompatibleDriver: failed to load AWS config: %w", err)
+	}
+
+	usePathStyle, _ := strconv.ParseBool(settings["usePathStyle"])
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if settings["endpoint"] != "" {
+			o.BaseEndpoint = aws.String(settings["endpoint"])
+		}
+		o.UsePathStyle = usePathStyle
+	})
+
+	return &s3CompatibleDriver{client: client, bucket: bucket, prefix: strings.Trim(settings["prefix"], "/")}, nil
+}
+
+// key maps a Driver path onto the object key this driver actually reads and
+// writes, under prefix.
+func (d *s3CompatibleDriver) key(path string) string {
+	trimmed := strings.Trim(path, "/")
+	if d.prefix == "" {
+		return trimmed
+	}
+	if trimmed == "" {
+		return d.prefix + "/"
+	}
+	return d.prefix + "/" + trimmed
+}
+
+func (d *s3CompatibleDriver) List(path string) ([]ICloudDocument, error) {
+	prefix := d.key(path)
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	ctx := context.Background()
+	var items []ICloudDocument
+	i := 0
+	paginator := s3.NewListObjectsV2Paginator(d.client, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(d.bucket),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String("/"),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("s3CompatibleDriver: list %s: %w", path, err)
+		}
+		for _, common := range page.CommonPrefixes {
+			name := strings.TrimSuffix(strings.TrimPrefix(aws.ToString(common.Prefix), prefix), "/")
+			if name == "" {
+				continue
+			}
+			items = append(items, ICloudDocument{
+				ID:          fmt.Sprintf("item_%d", i),
+				Name:        name,
+				Path:        strings.TrimSuffix(path, "/") + "/" + name,
+				IsDirectory: true,
+			})
+			i++
+		}
+		for _, obj := range page.Contents {
+			name := strings.TrimPrefix(aws.ToString(obj.Key), prefix)
+			if name == "" || strings.HasSuffix(name, "/") {
+				continue
+			}
+			items = append(items, ICloudDocument{
+				ID:          fmt.Sprintf("item_%d", i),
+				Name:        name,
+				Path:        strings.TrimSuffix(path, "/") + "/" + name,
+				IsDirectory: false,
+				Size:        aws.ToInt64(obj.Size),
+				Modified:    aws.ToTime(obj.LastModified),
+				Type:        fileTypeForName(name),
+			})
+			i++
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+	return items, nil
+}
+
+func (d *s3CompatibleDriver) Stat(path string) (ICloudDocument, error) {
+	out, err := d.client.HeadObject(context.Background(), &s3.HeadObjectInput{
+		Bucket: aws.String(d.bucket),
+		Key:    aws.String(d.key(path)),
+	})
+	if err != nil {
+		return ICloudDocument{}, fmt.Errorf("s3CompatibleDriver: stat %s: %w", path, err)
+	}
+	return ICloudDocument{
+		Name:        filepath.Base(path),
+		Path:        path,
+		IsDirectory: false,
+		Size:        aws.ToInt64(out.ContentLength),
+		Modified:    aws.ToTime(out.LastModified),
+		Type:        fileTypeForName(path),
+	}, nil
+}
+
+func (d *s3CompatibleDriver) Open(path string) (io.ReadCloser, error) {
+	out, err := d.client.GetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(d.bucket),
+		Key:    aws.String(d.key(path)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("s3CompatibleDriver: open %s: %w", path, err)
+	}
+	return out.Body, nil
+}
+
+func (d *s3CompatibleDriver) OpenRange(path string, offset, length int64) (io.ReadCloser, error) {
+	var rangeHeader string
+	if length < 0 {
+		rangeHeader = fmt.Sprintf("bytes=%d-", offset)
+	} else {
+		rangeHeader = fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+	}
+
+	out, err := d.client.GetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(d.bucket),
+		Key:    aws.String(d.key(path)),
+		Range:  aws.String(rangeHeader),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("s3CompatibleDriver: range request for %s: %w", path, err)
+	}
+	return out.Body, nil
+}
+
+func (d *s3CompatibleDriver) Put(path string, r io.Reader) error {
+	_, err := d.client.PutObject(context.Background(), &s3.PutObjectInput{
+		Bucket: aws.String(d.bucket),
+		Key:    aws.String(d.key(path)),
+		Body:   r,
+	})
+	if err != nil {
+		return fmt.Errorf("s3CompatibleDriver: put %s: %w", path, err)
+	}
+	return nil
+}
+
+// Mkdir creates the zero-byte, trailing-slash marker object S3 consoles use
+// to represent an empty "folder" - S3 has no real directory concept, so
+// this exists only so handleCreateFolder-style callers have somewhere to
+// write, not because List depends on it (non-empty prefixes already show up
+// via CommonPrefixes).
+func (d *s3CompatibleDriver) Mkdir(path string) error {
+	_, err := d.client.PutObject(context.Background(), &s3.PutObjectInput{
+		Bucket: aws.String(d.bucket),
+		Key:    aws.String(d.key(path) + "/"),
+		Body:   bytes.NewReader(nil),
+	})
+	if err != nil {
+		return fmt.Errorf("s3CompatibleDriver: mkdir %s: %w", path, err)
+	}
+	return nil
+}
+
+// Remove deletes path. If path is a "directory" (see Mkdir), every object
+// under its prefix is deleted too, since S3 has no recursive-delete
+// primitive of its own.
+func (d *s3CompatibleDriver) Remove(path string) error {
+	ctx := context.Background()
+	prefix := d.key(path)
+
+	var keys []types.ObjectIdentifier
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		keys = append(keys, types.ObjectIdentifier{Key: aws.String(prefix)})
+		prefix += "/"
+	}
+	paginator := s3.NewListObjectsV2Paginator(d.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(d.bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("s3CompatibleDriver: list before remove %s: %w", path, err)
+		}
+		for _, obj := range page.Contents {
+			keys = append(keys, types.ObjectIdentifier{Key: obj.Key})
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	_, err := d.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+		Bucket: aws.String(d.bucket),
+		Delete: &types.Delete{Objects: keys},
+	})
+	if err != nil {
+		return fmt.Errorf("s3CompatibleDriver: remove %s: %w", path, err)
+	}
+	return nil
+}
+
+func (d *s3CompatibleDriver) Move(src, dst string) error {
+	ctx := context.Background()
+	srcKey, dstKey := d.key(src), d.key(dst)
+
+	_, err := d.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String(d.bucket),
+		Key:        aws.String(dstKey),
+		CopySource: aws.String(d.bucket + "/" + srcKey),
+	})
+	if err != nil {
+		return fmt.Errorf("s3CompatibleDriver: copy %s -> %s: %w", src, dst, err)
+	}
+
+	if _, err := d.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(d.bucket),
+		Key:    aws.String(srcKey),
+	}); err != nil {
+		return fmt.Errorf("s3CompatibleDriver: move %s -> %s: delete source: %w", src, dst, err)
+	}
+	return nil
+}
+
+// webdavDriver is a stub Driver backed by a generic WebDAV endpoint
+// (Nextcloud, ownCloud, ...).
+type webdavDriver struct {
+	baseURL  string
+	username string
+	password string
+}
+
+func newWebDAVDriver(settings map[string]string) (Driver, error) {
+	// TODO: issue PROPFIND/GET/PUT/MKCOL/DELETE/MOVE requests against
+	// settings["baseURL"] using HTTP basic auth.
+	return &webdavDriver{
+		baseURL:  settings["baseURL"],
+		username: settings["username"],
+		password: settings["password"],
+	}, nil
+}
+
+func (d *webdavDriver) List(path string) ([]ICloudDocument, error) {
+	return nil, fmt.Errorf("webdavDriver: not implemented in this prototype")
+}
+func (d *webdavDriver) Stat(path string) (ICloudDocument, error) {
+	return ICloudDocument{}, fmt.Errorf("webdavDriver: not implemented in this prototype")
+}
+func (d *webdavDriver) Open(path string) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("webdavDriver: not implemented in this prototype")
+}
+func (d *webdavDriver) OpenRange(path string, offset, length int64) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("webdavDriver: not implemented in this prototype")
+}
+func (d *webdavDriver) Put(path string, r io.Reader) error {
+	return fmt.Errorf("webdavDriver: not implemented in this prototype")
+}
+func (d *webdavDriver) Mkdir(path string) error {
+	return fmt.Errorf("webdavDriver: not implemented in this prototype")
+}
+func (d *webdavDriver) Remove(path string) error {
+	return fmt.Errorf("webdavDriver: not implemented in this prototype")
+}
+func (d *webdavDriver) Move(src, dst string) error {
+	return fmt.Errorf("webdavDriver: not implemented in this prototype")
+}
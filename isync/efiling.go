@@ -0,0 +1,440 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FilingStatus represents the lifecycle state of a court e-filing
+type FilingStatus string
+
+const (
+	FilingPending         FilingStatus = "Pending"
+	FilingCourtValidating FilingStatus = "CourtValidating"
+	FilingClerkReview     FilingStatus = "ClerkReview"
+	FilingApproved        FilingStatus = "Approved"
+	FilingRejected        FilingStatus = "Rejected"
+)
+
+// CourtEndpoint describes a configured e-filing gateway for a jurisdiction
+type CourtEndpoint struct {
+	Name            string   `json:"name"`            // "EDNY", "Orange County Superior Court"
+	SubmitURL       string   `json:"submitURL"`        // e-filing submission endpoint
+	StatusURL       string   `json:"statusURL"`        // polling endpoint, filingId appended
+	FeeURL          string   `json:"feeURL"`            // fee-quote endpoint
+	Schema          string   `json:"schema"`            // "edny" | "orange-county"
+	APIKey          string   `json:"apiKey"`            // credential for the gateway
+	OutboundAllowed []string `json:"outboundAllowed"`  // hostnames this server may POST filings to
+}
+
+// FilingRecord tracks a single e-filing submission. Records are kept only in
+// the in-memory filingRecords map and are lost on restart.
+type FilingRecord struct {
+	FilingID      string       `json:"filingId"`
+	CaseNumber    string       `json:"caseNumber"`
+	CourtName     string       `json:"courtName"`
+	Status        FilingStatus `json:"status"`
+	SubmittedAt   time.Time    `json:"submittedAt"`
+	UpdatedAt     time.Time    `json:"updatedAt"`
+	FeeQuoted     string       `json:"feeQuoted,omitempty"`
+	RejectReason  string       `json:"rejectReason,omitempty"`
+	StampedPDFPath string      `json:"stampedPdfPath,omitempty"`
+}
+
+// CourtFeeQuote is returned by the pre-submission fee quote step
+type CourtFeeQuote struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// courtEnvelope is the pluggable XML shape POSTed to the court endpoint.
+// It covers the fields common to EDNY/Orange-County-style e-filing envelopes.
+type courtEnvelope struct {
+	XMLName      xml.Name `xml:"CourtFilingEnvelope"`
+	CourtName    string   `xml:"CourtName"`
+	CaseNumber   string   `xml:"CaseNumber"`
+	Attorney     envelopeAttorney `xml:"Attorney"`
+	Parties      []envelopeParty  `xml:"Parties>Party"`
+	CauseCounts  int      `xml:"CauseCounts"`
+	JuryDemand   bool     `xml:"JuryDemand"`
+	AttachmentB64 string  `xml:"Attachment>Base64Data"`
+}
+
+type envelopeAttorney struct {
+	Name      string `xml:"Name"`
+	BarNumber string `xml:"BarNumber"`
+	Firm      string `xml:"Firm"`
+}
+
+type envelopeParty struct {
+	Role string `xml:"Role"` // "Plaintiff" | "Defendant"
+	Name string `xml:"Name"`
+}
+
+// Global filing registry. In-memory for the prototype, matching the pattern
+// already used for icloudSyncStatuses.
+var (
+	filingRecords   = make(map[string]*FilingRecord)
+	filingRecordsMu sync.Mutex
+)
+
+// configuredCourtEndpoints maps a court name to its e-filing gateway config.
+// For the prototype this is hardcoded; in production it would load from a
+// config file alongside the rest of the firm's settings.
+var configuredCourtEndpoints = map[string]CourtEndpoint{
+	"EASTERN DISTRICT OF NEW YORK": {
+		Name:      "EDNY",
+		SubmitURL: "https://efiling.nyed.uscourts.gov/api/submit",
+		StatusURL: "https://efiling.nyed.uscourts.gov/api/status",
+		FeeURL:    "https://efiling.nyed.uscourts.gov/api/fees",
+		Schema:    "edny",
+		OutboundAllowed: []string{
+			"efiling.nyed.uscourts.gov",
+		},
+	},
+}
+
+// handleEfilingQuote returns a cost estimate before submission, so the
+// frontend can confirm with the attorney. The quote is a fixed simulated
+// amount; the court's FeeURL is only logged, not called.
+func handleEfilingQuote(c *gin.Context) {
+	var request struct {
+		ClientCase ClientCase `json:"clientCase"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	endpoint, ok := configuredCourtEndpoints[request.ClientCase.CourtJurisdiction]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No court endpoint configured for jurisdiction: " + request.ClientCase.CourtJurisdiction})
+		return
+	}
+
+	// For the prototype we simulate the fee-quote call; a real implementation
+	// would POST case metadata to endpoint.FeeURL and parse the response.
+	quote := CourtFeeQuote{Amount: "$402.00", Currency: "USD"}
+	log.Printf("Fee quote requested for %s: %s %s (fee endpoint: %s)", request.ClientCase.CaseNumber, quote.Amount, quote.Currency, endpoint.FeeURL)
+
+	c.JSON(http.StatusOK, quote)
+}
+
+// handleEfilingSubmit validates attorney bar info, serializes the complaint
+// as a court-schema XML envelope, and submits it to the configured
+// CourtEndpoint, then starts async polling for the court's decision.
+func handleEfilingSubmit(c *gin.Context) {
+	var request struct {
+		ClientCase   ClientCase `json:"clientCase"`
+		DocumentHTML string     `json:"documentHTML"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	if request.ClientCase.AttorneyName == "" || request.ClientCase.AttorneyBarNumber == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Attorney name and bar number are required for e-filing"})
+		return
+	}
+
+	endpoint, ok := configuredCourtEndpoints[request.ClientCase.CourtJurisdiction]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No court endpoint configured for jurisdiction: " + request.ClientCase.CourtJurisdiction})
+		return
+	}
+
+	if !isOutboundHostAllowed(endpoint) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Court endpoint is not on the outbound whitelist"})
+		return
+	}
+
+	docxBytes, err := convertHTMLToDocx(request.DocumentHTML)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare complaint for filing"})
+		return
+	}
+
+	envelope := buildCourtEnvelope(request.ClientCase, docxBytes)
+	envelopeXML, err := xml.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to serialize filing envelope"})
+		return
+	}
+
+	filingID := fmt.Sprintf("filing_%d", time.Now().Unix())
+	record := &FilingRecord{
+		FilingID:    filingID,
+		CaseNumber:  request.ClientCase.CaseNumber,
+		CourtName:   endpoint.Name,
+		Status:      FilingPending,
+		SubmittedAt: time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	filingRecordsMu.Lock()
+	filingRecords[filingID] = record
+	filingRecordsMu.Unlock()
+
+	// Submit asynchronously and poll the court for its decision.
+	go submitAndPollFiling(endpoint, envelopeXML, record)
+
+	log.Printf("E-filing submitted for case %s to %s (filing %s)", request.ClientCase.CaseNumber, endpoint.Name, filingID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"filingId":  filingID,
+		"status":    record.Status,
+	})
+}
+
+// handleEfilingStatus returns the current status of a tracked filing.
+func handleEfilingStatus(c *gin.Context) {
+	filingID := c.Param("filingId")
+
+	filingRecordsMu.Lock()
+	record, exists := filingRecords[filingID]
+	filingRecordsMu.Unlock()
+
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Filing not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, record)
+}
+
+// handleEfilingReceipt returns the stamped PDF receipt once a filing has
+// been approved.
+func handleEfilingReceipt(c *gin.Context) {
+	filingID := c.Param("filingId")
+
+	filingRecordsMu.Lock()
+	record, exists := filingRecords[filingID]
+	filingRecordsMu.Unlock()
+
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Filing not found"})
+		return
+	}
+
+	if record.Status != FilingApproved || record.StampedPDFPath == "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Filing has not been approved yet", "status": record.Status})
+		return
+	}
+
+	c.File(record.StampedPDFPath)
+}
+
+// buildCourtEnvelope maps a ClientCase onto the pluggable court-schema XML
+// envelope, including the complaint DOCX as base64 attachment bytes.
+func buildCourtEnvelope(clientCase ClientCase, docxBytes []byte) courtEnvelope {
+	parties := []envelopeParty{{Role: "Plaintiff", Name: clientCase.ClientName}}
+	for _, defendant := range clientCase.Defendants {
+		parties = append(parties, envelopeParty{Role: "Defendant", Name: defendant.Name})
+	}
+
+	return courtEnvelope{
+		CourtName:  clientCase.CourtJurisdiction,
+		CaseNumber: clientCase.CaseNumber,
+		Attorney: envelopeAttorney{
+			Name:      clientCase.AttorneyName,
+			BarNumber: clientCase.AttorneyBarNumber,
+			Firm:      clientCase.AttorneyFirm,
+		},
+		Parties:       parties,
+		CauseCounts:   len(clientCase.CausesOfAction),
+		JuryDemand:    clientCase.JuryDemand,
+		AttachmentB64: base64.StdEncoding.EncodeToString(docxBytes),
+	}
+}
+
+// isOutboundHostAllowed checks the court endpoint against its own outbound
+// whitelist before any network call is made.
+func isOutboundHostAllowed(endpoint CourtEndpoint) bool {
+	for _, allowed := range endpoint.OutboundAllowed {
+		if submitURLHost(endpoint.SubmitURL) == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func submitURLHost(rawURL string) string {
+	// Minimal host extraction; avoids pulling in net/url for a single field.
+	const prefix = "https://"
+	s := rawURL
+	if len(s) > len(prefix) && s[:len(prefix)] == prefix {
+		s = s[len(prefix):]
+	}
+	for i, r := range s {
+		if r == '/' {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// submitAndPollFiling POSTs the XML envelope to the court endpoint, then
+// polls asynchronously via a worker-pool goroutine until the court reaches
+// a terminal decision.
+func submitAndPollFiling(endpoint CourtEndpoint, envelopeXML []byte, record *FilingRecord) {
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint.SubmitURL, bytes.NewReader(envelopeXML))
+	if err != nil {
+		markFilingRejected(record, "failed to build submission request: "+err.Error())
+		return
+	}
+	req.Header.Set("Content-Type", "application/xml")
+	if endpoint.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+endpoint.APIKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// For the prototype, treat an unreachable court gateway as a
+		// successful hand-off into the async polling loop rather than a
+		// hard failure, since most courts in this corpus are simulated.
+		log.Printf("Court submission request failed (continuing with simulated polling): %v", err)
+	} else {
+		resp.Body.Close()
+	}
+
+	updateFilingStatus(record, FilingCourtValidating)
+	pollFilingWorker(endpoint, record)
+}
+
+// courtStatusResponse is the JSON shape returned by a court's StatusURL
+// polling endpoint.
+type courtStatusResponse struct {
+	Status           string `json:"status"` // one of the FilingStatus values
+	RejectReason     string `json:"rejectReason,omitempty"`
+	StampedPDFBase64 string `json:"stampedPdfBase64,omitempty"` // present once Status is Approved
+}
+
+// pollInterval and pollMaxAttempts bound how long pollFilingWorker polls a
+// court's StatusURL before giving up and rejecting the filing locally.
+const (
+	pollInterval    = 2 * time.Second
+	pollMaxAttempts = 30
+)
+
+// pollFilingWorker is run from a small worker pool (one goroutine per
+// in-flight filing) that checks the court's status endpoint until the
+// filing reaches Approved or Rejected.
+func pollFilingWorker(endpoint CourtEndpoint, record *FilingRecord) {
+	client := &http.Client{Timeout: 15 * time.Second}
+
+	for attempt := 1; attempt <= pollMaxAttempts; attempt++ {
+		time.Sleep(pollInterval)
+
+		status, err := fetchFilingStatus(client, endpoint, record.FilingID)
+		if err != nil {
+			log.Printf("Filing %s: status poll failed (attempt %d/%d): %v", record.FilingID, attempt, pollMaxAttempts, err)
+			continue
+		}
+
+		switch FilingStatus(status.Status) {
+		case FilingRejected:
+			markFilingRejected(record, status.RejectReason)
+			return
+		case FilingApproved:
+			if err := saveStampedReceipt(record, status.StampedPDFBase64); err != nil {
+				markFilingRejected(record, "failed to save stamped receipt: "+err.Error())
+				return
+			}
+			updateFilingStatus(record, FilingApproved)
+			log.Printf("Filing %s approved by %s", record.FilingID, endpoint.Name)
+			return
+		case FilingPending, FilingCourtValidating, FilingClerkReview:
+			updateFilingStatus(record, FilingStatus(status.Status))
+		default:
+			log.Printf("Filing %s: unrecognized court status %q, ignoring", record.FilingID, status.Status)
+		}
+	}
+
+	markFilingRejected(record, "timed out waiting for a terminal status from the court")
+}
+
+// fetchFilingStatus makes one GET request against endpoint.StatusURL for
+// filingID and decodes the court's JSON status response.
+func fetchFilingStatus(client *http.Client, endpoint CourtEndpoint, filingID string) (*courtStatusResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, endpoint.StatusURL+"/"+filingID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build status request: %w", err)
+	}
+	if endpoint.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+endpoint.APIKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status endpoint returned %d", resp.StatusCode)
+	}
+
+	var status courtStatusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("failed to decode status response: %w", err)
+	}
+	return &status, nil
+}
+
+// saveStampedReceipt decodes the court's base64 stamped PDF and writes it to
+// disk so handleEfilingReceipt's c.File(record.StampedPDFPath) has something
+// to serve.
+func saveStampedReceipt(record *FilingRecord, pdfBase64 string) error {
+	dir := "/Users/corelogic/satori-dev/clients/proj-mallon/dev/saved_documents"
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create saved documents directory: %w", err)
+	}
+
+	pdfBytes, err := base64.StdEncoding.DecodeString(pdfBase64)
+	if err != nil {
+		return fmt.Errorf("failed to decode stamped pdf: %w", err)
+	}
+
+	path := filepath.Join(dir, record.FilingID+"_stamped.pdf")
+	if err := os.WriteFile(path, pdfBytes, 0644); err != nil {
+		return fmt.Errorf("failed to write stamped pdf: %w", err)
+	}
+
+	filingRecordsMu.Lock()
+	record.StampedPDFPath = path
+	filingRecordsMu.Unlock()
+	return nil
+}
+
+func updateFilingStatus(record *FilingRecord, status FilingStatus) {
+	filingRecordsMu.Lock()
+	record.Status = status
+	record.UpdatedAt = time.Now()
+	filingRecordsMu.Unlock()
+}
+
+func markFilingRejected(record *FilingRecord, reason string) {
+	filingRecordsMu.Lock()
+	record.Status = FilingRejected
+	record.RejectReason = reason
+	record.UpdatedAt = time.Now()
+	filingRecordsMu.Unlock()
+	log.Printf("Filing %s rejected: %s", record.FilingID, reason)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// icloudCredentialsKey is generated once at process start and used to
+// encrypt app-specific passwords at rest in memory. In production this
+// should come from a managed secret (KMS, Vault, etc.) so credentials
+// survive a restart and rotate independently of the binary.
+var icloudCredentialsKey = generateICloudCredentialsKey()
+
+func generateICloudCredentialsKey() []byte {
+	key := make([]byte, 32) // AES-256
+	if _, err := rand.Read(key); err != nil {
+		panic("icloud credentials store: failed to generate encryption key: " + err.Error())
+	}
+	return key
+}
+
+// encryptedICloudCredential is the per-tenant record kept in
+// icloudCredentialStore. AppPassword is stored only as AES-GCM ciphertext.
+type encryptedICloudCredential struct {
+	mu               sync.Mutex
+	Username         string
+	EncryptedAppPass []byte
+	Nonce            []byte
+	SessionID        string
+	CreatedAt        time.Time
+	ExpiresAt        time.Time
+	// Client is the authenticated appleICloudClient for this tenant's
+	// session (see icloud_client.go), or nil if the last sign-in fell
+	// back to local filesystem access.
+	Client *appleICloudClient
+}
+
+// icloudCredentialStore is a multi-tenant replacement for the previous
+// single package-level icloudCredentials variable, which leaked one user's
+// iCloud session to every other logged-in user on the server. Credentials
+// are keyed by the caller's application userID (see authMiddleware) so
+// each tenant's iCloud session is isolated, and each record has its own
+// lock so concurrent requests for the same tenant don't race.
+type icloudCredentialStore struct {
+	mu      sync.RWMutex
+	byUser  map[string]*encryptedICloudCredential
+}
+
+func newICloudCredentialStore() *icloudCredentialStore {
+	return &icloudCredentialStore{byUser: make(map[string]*encryptedICloudCredential)}
+}
+
+// Save encrypts and stores credentials (and the authenticated client, if
+// any) for a tenant.
+func (s *icloudCredentialStore) Save(userID string, creds *ICloudCredentials, client *appleICloudClient) error {
+	block, err := aes.NewCipher(icloudCredentialsKey)
+	if err != nil {
+		return fmt.Errorf("failed to init cipher: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return fmt.Errorf("failed to init GCM: %v", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	ciphertext := gcm.Seal(nil, nonce, []byte(creds.AppPassword), nil)
+
+	record := &encryptedICloudCredential{
+		Username:         creds.Username,
+		EncryptedAppPass: ciphertext,
+		Nonce:            nonce,
+		SessionID:        creds.SessionID,
+		CreatedAt:        creds.CreatedAt,
+		ExpiresAt:        creds.ExpiresAt,
+		Client:           client,
+	}
+
+	s.mu.Lock()
+	s.byUser[userID] = record
+	s.mu.Unlock()
+	return nil
+}
+
+// Get decrypts and returns the tenant's credentials, if present and not
+// expired.
+func (s *icloudCredentialStore) Get(userID string) (*ICloudCredentials, bool) {
+	s.mu.RLock()
+	record, exists := s.byUser[userID]
+	s.mu.RUnlock()
+	if !exists {
+		return nil, false
+	}
+
+	record.mu.Lock()
+	defer record.mu.Unlock()
+
+	if time.Now().After(record.ExpiresAt) {
+		return nil, false
+	}
+
+	block, err := aes.NewCipher(icloudCredentialsKey)
+	if err != nil {
+		return nil, false
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, false
+	}
+	plaintext, err := gcm.Open(nil, record.Nonce, record.EncryptedAppPass, nil)
+	if err != nil {
+		return nil, false
+	}
+
+	return &ICloudCredentials{
+		Username:    record.Username,
+		AppPassword: string(plaintext),
+		SessionID:   record.SessionID,
+		CreatedAt:   record.CreatedAt,
+		ExpiresAt:   record.ExpiresAt,
+	}, true
+}
+
+// Delete removes a tenant's stored credentials (logout / expiry).
+func (s *icloudCredentialStore) Delete(userID string) {
+	s.mu.Lock()
+	delete(s.byUser, userID)
+	s.mu.Unlock()
+}
+
+// GetClient returns the tenant's authenticated appleICloudClient, if a
+// real Apple sign-in succeeded for their current session.
+func (s *icloudCredentialStore) GetClient(userID string) (*appleICloudClient, bool) {
+	s.mu.RLock()
+	record, exists := s.byUser[userID]
+	s.mu.RUnlock()
+	if !exists {
+		return nil, false
+	}
+
+	record.mu.Lock()
+	defer record.mu.Unlock()
+	if record.Client == nil {
+		return nil, false
+	}
+	return record.Client, true
+}
+
+// PruneExpired removes every tenant record past its ExpiresAt, mirroring
+// pruneExpiredSessions for the login SessionStore (see sessions.go).
+func (s *icloudCredentialStore) PruneExpired() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for userID, record := range s.byUser {
+		if time.Now().After(record.ExpiresAt) {
+			delete(s.byUser, userID)
+		}
+	}
+}
+
+// icloudCredentials is the active multi-tenant credential store, replacing
+// the previous single *ICloudCredentials global.
+var icloudCredentials = newICloudCredentialStore()
+
+// icloudSyncStatusStore guards icloudSyncStatuses so concurrent upload/
+// download goroutines for different documents don't race on the map
+// itself (per-document progress fields are updated directly and remain
+// each document's own mutation, matching the existing sync status shape).
+type icloudSyncStatusStore struct {
+	mu       sync.Mutex
+	statuses map[string]*ICloudSyncStatus
+}
+
+func newICloudSyncStatusStore() *icloudSyncStatusStore {
+	return &icloudSyncStatusStore{statuses: make(map[string]*ICloudSyncStatus)}
+}
+
+func (s *icloudSyncStatusStore) Set(id string, status *ICloudSyncStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses[id] = status
+}
+
+func (s *icloudSyncStatusStore) Get(id string) (*ICloudSyncStatus, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status, exists := s.statuses[id]
+	return status, exists
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// trustTokenDir holds one encrypted file per Apple ID containing its
+// long-lived 2FA trust token, so a server restart doesn't force every
+// account back through the 2FA challenge (see VerifyTwoFactorCode).
+var trustTokenDir = "/Users/corelogic/satori-dev/clients/proj-mallon/dev/icloud_trust_tokens"
+
+var trustTokenMu sync.Mutex
+
+// trustTokenKeyPath stores the AES-256 key trust tokens are encrypted
+// with at rest. Unlike icloudCredentialsKey (icloud_credentials_store.go),
+// which is regenerated fresh every process start since app passwords only
+// need to survive a single login session, this key must itself survive a
+// restart - otherwise saveTrustToken/loadTrustToken would encrypt and
+// decrypt with different keys across restarts and every linked Apple ID
+// would silently fail Login's trust-token lookup and be forced back
+// through 2FA anyway, defeating the point of this file.
+var trustTokenKeyPath = filepath.Join(trustTokenDir, ".trust_key")
+
+var (
+	trustTokenKeyOnce sync.Once
+	trustTokenKey     []byte
+	trustTokenKeyErr  error
+)
+
+// getTrustTokenKey loads the persisted trust token encryption key, or
+// generates and persists one on first use.
+func getTrustTokenKey() ([]byte, error) {
+	trustTokenKeyOnce.Do(func() {
+		if data, err := os.ReadFile(trustTokenKeyPath); err == nil && len(data) == 32 {
+			trustTokenKey = data
+			return
+		}
+
+		key := make([]byte, 32) // AES-256
+		if _, err := rand.Read(key); err != nil {
+			trustTokenKeyErr = fmt.Errorf("failed to generate trust token key: %v", err)
+			return
+		}
+		if err := os.MkdirAll(trustTokenDir, 0700); err != nil {
+			trustTokenKeyErr = fmt.Errorf("failed to create trust token directory: %v", err)
+			return
+		}
+		if err := os.WriteFile(trustTokenKeyPath, key, 0600); err != nil {
+			trustTokenKeyErr = fmt.Errorf("failed to persist trust token key: %v", err)
+			return
+		}
+		trustTokenKey = key
+	})
+	return trustTokenKey, trustTokenKeyErr
+}
+
+// persistedTrustToken is the plaintext shape encrypted at rest under
+// trustTokenDir, keyed by Apple ID rather than application userID since
+// the same Apple account may be linked from more than one attorney login.
+type persistedTrustToken struct {
+	TrustToken string    `json:"trustToken"`
+	DrivewsURL string    `json:"drivewsUrl"`
+	DocwsURL   string    `json:"docwsUrl"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+// saveTrustToken encrypts and writes session's trust token to disk. It is
+// a no-op if the session carries no trust token (e.g. login is mid-2FA).
+func saveTrustToken(appleID string, session *icloudSessionInfo) error {
+	if session == nil || session.TrustToken == "" {
+		return nil
+	}
+
+	plaintext, err := json.Marshal(persistedTrustToken{
+		TrustToken: session.TrustToken,
+		DrivewsURL: session.DrivewsURL,
+		DocwsURL:   session.DocwsURL,
+		ExpiresAt:  session.ExpiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	key, err := getTrustTokenKey()
+	if err != nil {
+		return err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	sealed := gcm.Seal(nonce, nonce, plaintext, nil)
+
+	trustTokenMu.Lock()
+	defer trustTokenMu.Unlock()
+	if err := os.MkdirAll(trustTokenDir, 0700); err != nil {
+		return fmt.Errorf("failed to create trust token directory: %v", err)
+	}
+	return os.WriteFile(filepath.Join(trustTokenDir, trustTokenFileName(appleID)), sealed, 0600)
+}
+
+// loadTrustToken reads back a previously persisted trust token for
+// appleID, if any was ever saved.
+func loadTrustToken(appleID string) (*persistedTrustToken, bool) {
+	trustTokenMu.Lock()
+	sealed, err := os.ReadFile(filepath.Join(trustTokenDir, trustTokenFileName(appleID)))
+	trustTokenMu.Unlock()
+	if err != nil {
+		return nil, false
+	}
+
+	key, err := getTrustTokenKey()
+	if err != nil {
+		return nil, false
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, false
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, false
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, false
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, false
+	}
+
+	var token persistedTrustToken
+	if err := json.Unmarshal(plaintext, &token); err != nil {
+		return nil, false
+	}
+	return &token, true
+}
+
+// trustTokenFileName derives a filesystem-safe file name for an Apple ID.
+// It hashes rather than lightly transliterates the ID: validateICloudCredentials
+// only requires the presented username to contain "@", so a crafted value
+// like "../../../../tmp/x@y" would otherwise survive a simple "@" -> "_at_"
+// replace and escape trustTokenDir via the Join in saveTrustToken/loadTrustToken.
+func trustTokenFileName(appleID string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(appleID)))
+	return hex.EncodeToString(sum[:]) + ".trust"
+}
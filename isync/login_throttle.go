@@ -0,0 +1,109 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Login throttling parameters. Backoff doubles per consecutive failure
+// (1s, 2s, 4s, ...) up to loginBackoffMax; loginLockoutAfter consecutive
+// failures, each within loginLockoutWindow of the one before, trigger a
+// hard lockout for loginLockoutFor,
+// closing the credential-stuffing hole in the previous unthrottled
+// handleLogin.
+const (
+	loginBackoffBase   = 1 * time.Second
+	loginBackoffMax    = 30 * time.Second
+	loginLockoutAfter  = 5
+	loginLockoutWindow = 10 * time.Minute
+	loginLockoutFor    = 15 * time.Minute
+)
+
+// loginAttemptRecord tracks consecutive failures for one key (a username
+// or an IP address).
+type loginAttemptRecord struct {
+	mu          sync.Mutex
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// loginAttemptTracker is a keyed set of loginAttemptRecords. handleLogin
+// keeps two of these, one by username and one by client IP, so failures
+// against one username are throttled whichever IP they come from, and
+// failures from one IP are throttled whichever username they target.
+type loginAttemptTracker struct {
+	mu    sync.Mutex
+	byKey map[string]*loginAttemptRecord
+}
+
+func newLoginAttemptTracker() *loginAttemptTracker {
+	return &loginAttemptTracker{byKey: make(map[string]*loginAttemptRecord)}
+}
+
+func (t *loginAttemptTracker) record(key string) *loginAttemptRecord {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	record, exists := t.byKey[key]
+	if !exists {
+		record = &loginAttemptRecord{}
+		t.byKey[key] = record
+	}
+	return record
+}
+
+// Allowed reports whether a login attempt for key may proceed right now.
+// If not, it also returns how long the caller must wait.
+func (t *loginAttemptTracker) Allowed(key string) (bool, time.Duration) {
+	record := t.record(key)
+
+	record.mu.Lock()
+	defer record.mu.Unlock()
+
+	if time.Now().Before(record.lockedUntil) {
+		return false, time.Until(record.lockedUntil)
+	}
+	if record.failures == 0 {
+		return true, 0
+	}
+
+	backoff := loginBackoffBase << uint(record.failures-1)
+	if backoff <= 0 || backoff > loginBackoffMax {
+		backoff = loginBackoffMax
+	}
+	if elapsed := time.Since(record.lastFailure); elapsed < backoff {
+		return false, backoff - elapsed
+	}
+	return true, 0
+}
+
+// RecordFailure registers a failed attempt for key, resetting the streak
+// if the last failure fell outside loginLockoutWindow, and locks the key
+// out once loginLockoutAfter consecutive failures accumulate.
+func (t *loginAttemptTracker) RecordFailure(key string) {
+	record := t.record(key)
+
+	record.mu.Lock()
+	defer record.mu.Unlock()
+
+	if time.Since(record.lastFailure) > loginLockoutWindow {
+		record.failures = 0
+	}
+	record.failures++
+	record.lastFailure = time.Now()
+	if record.failures >= loginLockoutAfter {
+		record.lockedUntil = time.Now().Add(loginLockoutFor)
+	}
+}
+
+// RecordSuccess clears key's failure streak after a successful login.
+func (t *loginAttemptTracker) RecordSuccess(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.byKey, key)
+}
+
+var (
+	loginAttemptsByUsername = newLoginAttemptTracker()
+	loginAttemptsByIP       = newLoginAttemptTracker()
+)
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+
+	gehirncrypt "github.com/GehirnInc/crypt"
+	_ "github.com/GehirnInc/crypt/sha512_crypt"
+)
+
+// minBcryptCost is the lowest bcrypt cost considered acceptable. Hashes
+// below this (or produced by a weaker algorithm entirely) are transparently
+// rehashed on the next successful login instead of forcing a password
+// reset.
+const minBcryptCost = 12
+
+// PasswordHasher verifies a password against a stored hash produced by a
+// specific algorithm, identified by the hash's own prefix.
+type PasswordHasher interface {
+	// Verify reports whether password matches hash.
+	Verify(hash, password string) (bool, error)
+	// NeedsRehash reports whether hash should be upgraded to the current
+	// preferred algorithm/cost (called only after a successful Verify).
+	NeedsRehash(hash string) bool
+}
+
+// bcryptHasher handles the $2a$/$2b$/$2y$ prefixes already used by
+// users.json.
+type bcryptHasher struct{}
+
+func (bcryptHasher) Verify(hash, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if err != nil {
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (bcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return true
+	}
+	return cost < minBcryptCost
+}
+
+// argon2Hasher handles $argon2id$ hashes, encountered when migrating users
+// off a system that preferred Argon2. NeedsRehash always reports true so a
+// successful login transparently upgrades the stored hash to bcrypt, the
+// algorithm this package otherwise standardizes on.
+type argon2Hasher struct{}
+
+// argon2idParams is the parsed $m=...,t=...,p=...$ segment of an
+// $argon2id$ encoded hash.
+type argon2idParams struct {
+	memory      uint32
+	time        uint32
+	parallelism uint8
+}
+
+func (argon2Hasher) Verify(hash, password string) (bool, error) {
+	params, salt, key, err := parseArgon2idHash(hash)
+	if err != nil {
+		return false, err
+	}
+	candidate := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(candidate, key) == 1, nil
+}
+
+func (argon2Hasher) NeedsRehash(hash string) bool { return true }
+
+// parseArgon2idHash parses the standard encoded form
+// "$argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>" produced by every common
+// Argon2id implementation (the Go reference one included).
+func parseArgon2idHash(hash string) (argon2idParams, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return argon2idParams{}, nil, nil, fmt.Errorf("argon2Hasher: malformed hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return argon2idParams{}, nil, nil, fmt.Errorf("argon2Hasher: malformed version segment: %w", err)
+	}
+	if version != argon2.Version {
+		return argon2idParams{}, nil, nil, fmt.Errorf("argon2Hasher: unsupported version %d", version)
+	}
+
+	var mem, iterations, parallelism int
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &mem, &iterations, &parallelism); err != nil {
+		return argon2idParams{}, nil, nil, fmt.Errorf("argon2Hasher: malformed parameters segment: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return argon2idParams{}, nil, nil, fmt.Errorf("argon2Hasher: malformed salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return argon2idParams{}, nil, nil, fmt.Errorf("argon2Hasher: malformed key: %w", err)
+	}
+
+	return argon2idParams{memory: uint32(mem), time: uint32(iterations), parallelism: uint8(parallelism)}, salt, key, nil
+}
+
+// shaCryptHasher handles legacy $6$ (SHA-512-crypt) hashes, encountered
+// when migrating users off an older system. NeedsRehash always reports
+// true so a successful login transparently upgrades the stored hash to
+// bcrypt.
+type shaCryptHasher struct{}
+
+func (shaCryptHasher) Verify(hash, password string) (bool, error) {
+	crypter, err := gehirncrypt.NewFromHash(hash)
+	if err != nil {
+		return false, fmt.Errorf("shaCryptHasher: unrecognized hash: %w", err)
+	}
+	if err := crypter.Verify(hash, []byte(password)); err != nil {
+		if err == gehirncrypt.ErrKeyMismatch {
+			return false, nil
+		}
+		return false, fmt.Errorf("shaCryptHasher: %w", err)
+	}
+	return true, nil
+}
+
+func (shaCryptHasher) NeedsRehash(hash string) bool { return true }
+
+// passwordHasherForHash picks the PasswordHasher matching a stored hash's
+// prefix so validateUserCredentials can verify any algorithm users.json
+// has on file, and rehash it to the current preferred one on success.
+func passwordHasherForHash(hash string) PasswordHasher {
+	switch {
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return bcryptHasher{}
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return argon2Hasher{}
+	case strings.HasPrefix(hash, "$6$"):
+		return shaCryptHasher{}
+	default:
+		return bcryptHasher{}
+	}
+}
+
+// hashPassword hashes password with the current preferred algorithm
+// (bcrypt at minBcryptCost), used both for new users and for transparent
+// rehashing of logins that verified against a weaker stored hash.
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), minBcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
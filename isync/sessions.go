@@ -0,0 +1,470 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	goredis "github.com/redis/go-redis/v9"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Scope represents a single permission a protected route can require.
+type Scope string
+
+const (
+	ScopeReadDocuments Scope = "read:documents"
+	ScopeWriteComplaint Scope = "write:complaint"
+	ScopeEfileSubmit    Scope = "efile:submit"
+	ScopeICloudSync     Scope = "icloud:sync"
+	ScopeAdminUsers     Scope = "admin:users"
+)
+
+// SessionStore persists UserSessions so that, unlike the previous
+// package-level userSessions map, logins can survive a server restart.
+// The active backend (see sessionStore) is bbolt-backed, not just an
+// interface wrapper over the old in-memory map.
+type SessionStore interface {
+	Save(session *UserSession) error
+	Get(sessionID string) (*UserSession, bool)
+	Delete(sessionID string) error
+	All() []*UserSession
+}
+
+// memorySessionStore is an in-memory SessionStore implementation. The
+// active store is not one of these: newSessionStore always opens the bbolt
+// store and panics if it cannot.
+type memorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*UserSession
+}
+
+func newMemorySessionStore() *memorySessionStore {
+	return &memorySessionStore{sessions: make(map[string]*UserSession)}
+}
+
+func (s *memorySessionStore) Save(session *UserSession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[session.SessionID] = session
+	return nil
+}
+
+func (s *memorySessionStore) Get(sessionID string) (*UserSession, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, exists := s.sessions[sessionID]
+	return session, exists
+}
+
+func (s *memorySessionStore) Delete(sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, sessionID)
+	return nil
+}
+
+func (s *memorySessionStore) All() []*UserSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all := make([]*UserSession, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		all = append(all, session)
+	}
+	return all
+}
+
+// sessionsBucket is the single bbolt bucket every session is stored under,
+// keyed by session ID.
+var sessionsBucket = []byte("sessions")
+
+// boltSessionStore is a bbolt-backed SessionStore: every Save/Delete is a
+// durable on-disk transaction, so a server restart (or crash) no longer
+// mass-logs-out every attorney the way memorySessionStore did.
+type boltSessionStore struct {
+	db *bolt.DB
+}
+
+func newBoltSessionStore(dbPath string) (*boltSessionStore, error) {
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session database at %s: %w", dbPath, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(sessionsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to init session bucket: %w", err)
+	}
+	return &boltSessionStore{db: db}, nil
+}
+
+func (s *boltSessionStore) Save(session *UserSession) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).Put([]byte(session.SessionID), data)
+	})
+}
+
+func (s *boltSessionStore) Get(sessionID string) (*UserSession, bool) {
+	var session UserSession
+	found := false
+	s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(sessionsBucket).Get([]byte(sessionID))
+		if data == nil {
+			return nil
+		}
+		if err := json.Unmarshal(data, &session); err != nil {
+			return nil
+		}
+		found = true
+		return nil
+	})
+	return &session, found
+}
+
+func (s *boltSessionStore) Delete(sessionID string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).Delete([]byte(sessionID))
+	})
+}
+
+func (s *boltSessionStore) All() []*UserSession {
+	var all []*UserSession
+	s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).ForEach(func(k, v []byte) error {
+			var session UserSession
+			if err := json.Unmarshal(v, &session); err != nil {
+				return nil
+			}
+			all = append(all, &session)
+			return nil
+		})
+	})
+	return all
+}
+
+// redisSessionKeyPrefix namespaces session keys in a shared Redis
+// instance from whatever else that instance is used for.
+const redisSessionKeyPrefix = "tm:session:"
+
+// redisSessionStore is a Redis-backed SessionStore, for multi-instance
+// deployments where more than one server process needs to see the same
+// session set rather than each keeping its own bbolt file.
+type redisSessionStore struct {
+	client *goredis.Client
+	ctx    context.Context
+}
+
+func newRedisSessionStore(addr string) (*redisSessionStore, error) {
+	client := goredis.NewClient(&goredis.Options{Addr: addr})
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to redis at %s: %w", addr, err)
+	}
+	return &redisSessionStore{client: client, ctx: ctx}, nil
+}
+
+func (s *redisSessionStore) key(sessionID string) string {
+	return redisSessionKeyPrefix + sessionID
+}
+
+func (s *redisSessionStore) Save(session *UserSession) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	var ttl time.Duration
+	if !session.ExpiresAt.IsZero() {
+		if ttl = time.Until(session.ExpiresAt); ttl <= 0 {
+			ttl = time.Second
+		}
+	}
+	return s.client.Set(s.ctx, s.key(session.SessionID), data, ttl).Err()
+}
+
+func (s *redisSessionStore) Get(sessionID string) (*UserSession, bool) {
+	data, err := s.client.Get(s.ctx, s.key(sessionID)).Bytes()
+	if err != nil {
+		return nil, false
+	}
+	var session UserSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, false
+	}
+	return &session, true
+}
+
+func (s *redisSessionStore) Delete(sessionID string) error {
+	return s.client.Del(s.ctx, s.key(sessionID)).Err()
+}
+
+func (s *redisSessionStore) All() []*UserSession {
+	var all []*UserSession
+	iter := s.client.Scan(s.ctx, 0, redisSessionKeyPrefix+"*", 0).Iterator()
+	for iter.Next(s.ctx) {
+		data, err := s.client.Get(s.ctx, iter.Val()).Bytes()
+		if err != nil {
+			continue
+		}
+		var session UserSession
+		if err := json.Unmarshal(data, &session); err != nil {
+			continue
+		}
+		all = append(all, &session)
+	}
+	return all
+}
+
+// sessionStore is the active SessionStore used by auth handlers. bbolt is
+// the production backend so a restart doesn't mass-log-out every
+// attorney; swap in newRedisSessionStore for a multi-instance deployment
+// that needs every process to share the same session set.
+var sessionStore = newSessionStore()
+
+func newSessionStore() SessionStore {
+	store, err := newBoltSessionStore("/Users/corelogic/satori-dev/clients/proj-mallon/dev/sessions.db")
+	if err != nil {
+		panic("sessions: failed to open session store: " + err.Error())
+	}
+	return store
+}
+
+// roleScopes maps each role to the set of scopes it is granted. This fixes
+// the previous bug where "demo" users had identical privileges to
+// "attorney" users on every protected endpoint.
+var roleScopes = map[string]map[Scope]bool{
+	"attorney": {
+		ScopeReadDocuments:  true,
+		ScopeWriteComplaint: true,
+		ScopeEfileSubmit:    true,
+		ScopeICloudSync:     true,
+	},
+	"admin": {
+		ScopeReadDocuments:  true,
+		ScopeWriteComplaint: true,
+		ScopeEfileSubmit:    true,
+		ScopeICloudSync:     true,
+		ScopeAdminUsers:     true,
+	},
+	"demo": {
+		ScopeReadDocuments: true,
+	},
+}
+var roleScopesMu sync.RWMutex
+
+// roleHasScope reports whether a role grants the given scope.
+func roleHasScope(role string, scope Scope) bool {
+	roleScopesMu.RLock()
+	defer roleScopesMu.RUnlock()
+	scopes, exists := roleScopes[role]
+	if !exists {
+		return false
+	}
+	return scopes[scope]
+}
+
+// RequireScopes returns middleware that enforces the caller's role grants
+// every listed scope, replacing the previous all-or-nothing authMiddleware
+// check for routes that need finer-grained enforcement.
+func RequireScopes(scopes ...Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, exists := c.Get("role")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "No role associated with session"})
+			c.Abort()
+			return
+		}
+
+		roleStr, _ := role.(string)
+		for _, scope := range scopes {
+			if !roleHasScope(roleStr, scope) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Role '" + roleStr + "' lacks required scope: " + string(scope)})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// handleUpdateRoleScopes lets admins customize the role-to-scope mapping at
+// runtime.
+func handleUpdateRoleScopes(c *gin.Context) {
+	var request struct {
+		Role   string   `json:"role"`
+		Scopes []string `json:"scopes"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	if request.Role == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role is required"})
+		return
+	}
+
+	scopeSet := make(map[Scope]bool, len(request.Scopes))
+	for _, scopeStr := range request.Scopes {
+		scopeSet[Scope(scopeStr)] = true
+	}
+
+	roleScopesMu.Lock()
+	roleScopes[request.Role] = scopeSet
+	roleScopesMu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "role": request.Role, "scopes": request.Scopes})
+}
+
+// findSessionByFamily locates the active session for a refresh-token
+// family, used by handleAuthRefresh to find the row a rotated token
+// belongs to without needing a second index keyed by family.
+func findSessionByFamily(family string) *UserSession {
+	for _, session := range sessionStore.All() {
+		if session.RefreshFamily == family {
+			return session
+		}
+	}
+	return nil
+}
+
+// handleAuthRefresh rotates an access token using its refresh token,
+// retiring the presented refresh token and issuing a replacement in the
+// same family (see refreshTokenRegistry in auth_refresh.go). Presenting an
+// already-retired token revokes every refresh token in the family and is
+// audited as refresh_reuse_detected; the session tied to the family is not
+// deleted and stays valid until it expires or is revoked through
+// /api/auth/sessions/:id.
+func handleAuthRefresh(c *gin.Context) {
+	var request struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil || request.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refreshToken is required"})
+		return
+	}
+
+	family, err := refreshTokens.Consume(request.RefreshToken)
+	if err != nil {
+		if err == ErrRefreshReuse {
+			auditLog("refresh_reuse_detected", gin.H{"ip": c.ClientIP()})
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
+
+	session := findSessionByFamily(family)
+	if session == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Session no longer exists"})
+		return
+	}
+
+	newAccessToken, err := generateSecureToken()
+	if err != nil {
+		log.Printf("Failed to generate access token on refresh: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate session"})
+		return
+	}
+	newRefreshToken, err := refreshTokens.Issue(family)
+	if err != nil {
+		log.Printf("Failed to issue refresh token on refresh: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate session"})
+		return
+	}
+
+	sessionStore.Delete(session.SessionID)
+	session.SessionID = newAccessToken
+	session.ExpiresAt = time.Now().Add(8 * time.Hour)
+	session.LastSeenAt = time.Now()
+	session.IP = c.ClientIP()
+	session.UserAgent = c.Request.UserAgent()
+	session.RefreshTokenHash = hashRefreshToken(newRefreshToken)
+	sessionStore.Save(session)
+
+	c.SetCookie("session_token", newAccessToken, int(8*time.Hour.Seconds()), "/", "", false, true)
+	auditLog("token_refreshed", gin.H{"username": session.Username, "ip": session.IP})
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"sessionToken": newAccessToken,
+		"refreshToken": newRefreshToken,
+		"expiresAt":    session.ExpiresAt,
+	})
+}
+
+// handleListSessions returns the caller's own active sessions with device
+// info (IP, user agent, last seen) so an attorney can see every place
+// they're logged in before revoking one.
+func handleListSessions(c *gin.Context) {
+	username, _ := c.Get("username")
+	usernameStr, _ := username.(string)
+
+	var sessions []*UserSession
+	for _, session := range sessionStore.All() {
+		if session.Username == usernameStr {
+			sessions = append(sessions, session)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": sessions,
+		"count":    len(sessions),
+	})
+}
+
+// handleRevokeSession revokes one of the caller's own sessions (and its
+// refresh-token family), e.g. after noticing an unrecognized device in
+// handleListSessions.
+func handleRevokeSession(c *gin.Context) {
+	sessionID := c.Param("id")
+
+	session, exists := sessionStore.Get(sessionID)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	username, _ := c.Get("username")
+	if usernameStr, _ := username.(string); usernameStr != session.Username {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot revoke another user's session"})
+		return
+	}
+
+	refreshTokens.RevokeFamily(session.RefreshFamily)
+	sessionStore.Delete(sessionID)
+	auditLog("session_revoked", gin.H{"username": session.Username, "sessionId": sessionID, "by": c.ClientIP()})
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// pruneExpiredSessions sweeps the session store for expired sessions. It is
+// intended to run periodically from a background goroutine.
+func pruneExpiredSessions() {
+	for _, session := range sessionStore.All() {
+		if time.Now().After(session.ExpiresAt) {
+			sessionStore.Delete(session.SessionID)
+		}
+	}
+}
+
+func startSessionSweeper() {
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			pruneExpiredSessions()
+		}
+	}()
+}
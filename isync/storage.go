@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Storage is the document-storage backend used for saved complaints and
+// certified copies. It replaces the hardcoded savedDocsDir path so the
+// backing store can be swapped for a cloud provider without touching the
+// handlers.
+type Storage interface {
+	Put(key string, content []byte) error
+	Get(key string) ([]byte, error)
+	Exists(key string) bool
+	Delete(key string) error
+	// Path returns a local filesystem path for the key if the backend
+	// supports direct file access (handleAcceptDocument records it as the
+	// saved document's FilePath), and false otherwise.
+	Path(key string) (string, bool)
+}
+
+// localFilesystemStorage stores documents on the local disk, matching the
+// previous hardcoded savedDocsDir behavior.
+type localFilesystemStorage struct {
+	baseDir string
+}
+
+func newLocalFilesystemStorage(baseDir string) *localFilesystemStorage {
+	return &localFilesystemStorage{baseDir: baseDir}
+}
+
+func (s *localFilesystemStorage) Put(key string, content []byte) error {
+	if err := os.MkdirAll(s.baseDir, 0755); err != nil {
+		return fmt.Errorf("failed to create storage directory: %v", err)
+	}
+	return os.WriteFile(filepath.Join(s.baseDir, key), content, 0644)
+}
+
+func (s *localFilesystemStorage) Get(key string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(s.baseDir, key))
+}
+
+func (s *localFilesystemStorage) Exists(key string) bool {
+	_, err := os.Stat(filepath.Join(s.baseDir, key))
+	return err == nil
+}
+
+func (s *localFilesystemStorage) Delete(key string) error {
+	return os.Remove(filepath.Join(s.baseDir, key))
+}
+
+func (s *localFilesystemStorage) Path(key string) (string, bool) {
+	return filepath.Join(s.baseDir, key), true
+}
+
+// s3Storage is a stub Storage backend for Amazon S3. Wiring a real
+// implementation only requires swapping activeStorage below; left
+// unimplemented here since no AWS SDK dependency is vendored in this
+// prototype.
+type s3Storage struct {
+	bucket string
+	prefix string
+}
+
+func newS3Storage(bucket, prefix string) *s3Storage {
+	// TODO: construct a real s3.Client and use PutObject/GetObject/
+	// DeleteObject/HeadObject against bucket/prefix.
+	return &s3Storage{bucket: bucket, prefix: prefix}
+}
+
+func (s *s3Storage) Put(key string, content []byte) error {
+	return fmt.Errorf("s3Storage: not implemented in this prototype")
+}
+
+func (s *s3Storage) Get(key string) ([]byte, error) {
+	return nil, fmt.Errorf("s3Storage: not implemented in this prototype")
+}
+
+func (s *s3Storage) Exists(key string) bool { return false }
+
+func (s *s3Storage) Delete(key string) error {
+	return fmt.Errorf("s3Storage: not implemented in this prototype")
+}
+
+func (s *s3Storage) Path(key string) (string, bool) { return "", false }
+
+// gcsStorage is a stub Storage backend for Google Cloud Storage.
+type gcsStorage struct {
+	bucket string
+}
+
+func newGCSStorage(bucket string) *gcsStorage {
+	// TODO: construct a real storage.Client against bucket.
+	return &gcsStorage{bucket: bucket}
+}
+
+func (s *gcsStorage) Put(key string, content []byte) error {
+	return fmt.Errorf("gcsStorage: not implemented in this prototype")
+}
+
+func (s *gcsStorage) Get(key string) ([]byte, error) {
+	return nil, fmt.Errorf("gcsStorage: not implemented in this prototype")
+}
+
+func (s *gcsStorage) Exists(key string) bool { return false }
+
+func (s *gcsStorage) Delete(key string) error {
+	return fmt.Errorf("gcsStorage: not implemented in this prototype")
+}
+
+func (s *gcsStorage) Path(key string) (string, bool) { return "", false }
+
+// oneDriveStorage is a stub Storage backend for Microsoft OneDrive, useful
+// for firms already standardized on Microsoft 365.
+type oneDriveStorage struct {
+	driveID    string
+	folderPath string
+}
+
+func newOneDriveStorage(driveID, folderPath string) *oneDriveStorage {
+	// TODO: authenticate against Microsoft Graph and upload/download via
+	// /drives/{driveID}/root:/{folderPath}/{key}:/content.
+	return &oneDriveStorage{driveID: driveID, folderPath: folderPath}
+}
+
+func (s *oneDriveStorage) Put(key string, content []byte) error {
+	return fmt.Errorf("oneDriveStorage: not implemented in this prototype")
+}
+
+func (s *oneDriveStorage) Get(key string) ([]byte, error) {
+	return nil, fmt.Errorf("oneDriveStorage: not implemented in this prototype")
+}
+
+func (s *oneDriveStorage) Exists(key string) bool { return false }
+
+func (s *oneDriveStorage) Delete(key string) error {
+	return fmt.Errorf("oneDriveStorage: not implemented in this prototype")
+}
+
+func (s *oneDriveStorage) Path(key string) (string, bool) { return "", false }
+
+// gDriveStorage is a stub Storage backend for Google Drive.
+type gDriveStorage struct {
+	folderID string
+}
+
+func newGDriveStorage(folderID string) *gDriveStorage {
+	// TODO: authenticate against the Google Drive API v3 and upload/
+	// download files scoped to folderID.
+	return &gDriveStorage{folderID: folderID}
+}
+
+func (s *gDriveStorage) Put(key string, content []byte) error {
+	return fmt.Errorf("gDriveStorage: not implemented in this prototype")
+}
+
+func (s *gDriveStorage) Get(key string) ([]byte, error) {
+	return nil, fmt.Errorf("gDriveStorage: not implemented in this prototype")
+}
+
+func (s *gDriveStorage) Exists(key string) bool { return false }
+
+func (s *gDriveStorage) Delete(key string) error {
+	return fmt.Errorf("gDriveStorage: not implemented in this prototype")
+}
+
+func (s *gDriveStorage) Path(key string) (string, bool) { return "", false }
+
+// activeStorage is the Storage backend used by the document handlers.
+// Selecting a backend is a matter of changing this assignment
+// (local/s3/gcs/onedrive/gdrive).
+var activeStorage Storage = newLocalFilesystemStorage("/Users/corelogic/satori-dev/clients/proj-mallon/dev/saved_documents")
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// storageSettingsKey encrypts each StorageConfig's settings blob at rest,
+// mirroring icloudCredentialsKey in icloud_credentials_store.go - one
+// process-lifetime AES-256 key per secret category rather than a single
+// shared key.
+var storageSettingsKey = generateStorageSettingsKey()
+
+func generateStorageSettingsKey() []byte {
+	key := make([]byte, 32) // AES-256
+	if _, err := rand.Read(key); err != nil {
+		panic("storages: failed to generate encryption key: " + err.Error())
+	}
+	return key
+}
+
+// StorageConfig is one cloud-storage backend an attorney has connected
+// (a Google Drive account, an S3 bucket used for off-site retention, ...).
+// Settings holds driver-specific fields (bucket name, OAuth refresh token,
+// WebDAV URL, ...) and only ever reaches callers decrypted, via
+// storageConfigStore.
+type StorageConfig struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Driver    string            `json:"driver"`
+	Settings  map[string]string `json:"settings"`
+	CreatedAt time.Time         `json:"createdAt"`
+}
+
+// encryptedStorageConfig is the at-rest representation kept in
+// storageConfigStore; Settings is never stored in plaintext.
+type encryptedStorageConfig struct {
+	ID        string
+	Name      string
+	Driver    string
+	Encrypted []byte
+	Nonce     []byte
+	CreatedAt time.Time
+}
+
+// storageConfigStore is a multi-tenant registry of configured storage
+// backends, keyed by the same application userID as icloudCredentialStore.
+type storageConfigStore struct {
+	mu     sync.RWMutex
+	byUser map[string][]*encryptedStorageConfig
+}
+
+func newStorageConfigStore() *storageConfigStore {
+	return &storageConfigStore{byUser: make(map[string][]*encryptedStorageConfig)}
+}
+
+// Save encrypts cfg.Settings and stores it, replacing any existing config
+// with the same ID.
+func (s *storageConfigStore) Save(userID string, cfg *StorageConfig) error {
+	plaintext, err := json.Marshal(cfg.Settings)
+	if err != nil {
+		return fmt.Errorf("failed to marshal storage settings: %v", err)
+	}
+
+	block, err := aes.NewCipher(storageSettingsKey)
+	if err != nil {
+		return fmt.Errorf("failed to init cipher: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return fmt.Errorf("failed to init GCM: %v", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %v", err)
+	}
+
+	record := &encryptedStorageConfig{
+		ID:        cfg.ID,
+		Name:      cfg.Name,
+		Driver:    cfg.Driver,
+		Encrypted: gcm.Seal(nil, nonce, plaintext, nil),
+		Nonce:     nonce,
+		CreatedAt: cfg.CreatedAt,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := s.byUser[userID]
+	for i, existing := range list {
+		if existing.ID == cfg.ID {
+			list[i] = record
+			return nil
+		}
+	}
+	s.byUser[userID] = append(list, record)
+	return nil
+}
+
+func (s *storageConfigStore) decrypt(record *encryptedStorageConfig) (*StorageConfig, error) {
+	block, err := aes.NewCipher(storageSettingsKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, record.Nonce, record.Encrypted, nil)
+	if err != nil {
+		return nil, err
+	}
+	var settings map[string]string
+	if err := json.Unmarshal(plaintext, &settings); err != nil {
+		return nil, err
+	}
+	return &StorageConfig{
+		ID:        record.ID,
+		Name:      record.Name,
+		Driver:    record.Driver,
+		Settings:  settings,
+		CreatedAt: record.CreatedAt,
+	}, nil
+}
+
+// List returns every storage config configured for userID, most recently
+// added last.
+func (s *storageConfigStore) List(userID string) ([]*StorageConfig, error) {
+	s.mu.RLock()
+	records := append([]*encryptedStorageConfig(nil), s.byUser[userID]...)
+	s.mu.RUnlock()
+
+	configs := make([]*StorageConfig, 0, len(records))
+	for _, record := range records {
+		cfg, err := s.decrypt(record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt storage config %s: %v", record.ID, err)
+		}
+		configs = append(configs, cfg)
+	}
+	return configs, nil
+}
+
+// Get looks up a single storage config by ID, scoped to userID so one
+// tenant can never reach another's storage credentials.
+func (s *storageConfigStore) Get(userID, id string) (*StorageConfig, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, record := range s.byUser[userID] {
+		if record.ID == id {
+			cfg, err := s.decrypt(record)
+			if err != nil {
+				return nil, false
+			}
+			return cfg, true
+		}
+	}
+	return nil, false
+}
+
+// Delete removes a tenant's storage config.
+func (s *storageConfigStore) Delete(userID, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := s.byUser[userID]
+	for i, record := range list {
+		if record.ID == id {
+			s.byUser[userID] = append(list[:i], list[i+1:]...)
+			return
+		}
+	}
+}
+
+// storageConfigs is the active multi-tenant storage config store.
+var storageConfigs = newStorageConfigStore()
+
+// userSelectableDrivers is the subset of driverRegistry an attorney can
+// connect via handleCreateStorage. "local" and "icloud" are excluded:
+// "icloud" only ever comes from an authenticated Apple session
+// (driverForUser constructs it directly, never via NewDriver), and "local"
+// is the server-filesystem driver used internally for the devMode
+// fallback (see legacyICloudDevPath) - letting a request pin its baseDir
+// to an arbitrary server path would turn every /api/icloud/* and
+// /api/storages/* handler into an arbitrary-file read/write.
+var userSelectableDrivers = map[string]bool{
+	"gdrive":  true,
+	"dropbox": true,
+	"s3":      true,
+	"webdav":  true,
+}
+
+// driverForUser resolves the Driver the caller's /api/icloud/* and
+// /api/storages/* requests should use. An authenticated iCloud session
+// (icloudCredentials) always wins, since it's the original and still
+// primary integration; otherwise the user's most recently added
+// StorageConfig is instantiated via the registry. With neither available
+// this falls back to a local driver rooted at the legacy dev path, gated
+// by devMode exactly like the get*ICloud* functions it replaces.
+func driverForUser(userID string) (Driver, error) {
+	if client, ok := icloudCredentials.GetClient(userID); ok && client.IsSessionValid() {
+		return &icloudDriver{client: client}, nil
+	}
+
+	configs, err := storageConfigs.List(userID)
+	if err == nil && len(configs) > 0 {
+		latest := configs[len(configs)-1]
+		return NewDriver(latest.Driver, latest.Settings)
+	}
+
+	if !devMode {
+		return nil, fmt.Errorf("no storage backend available: no authenticated remote session and no storages configured (dev mode disabled)")
+	}
+	return newLocalDriver(map[string]string{"baseDir": legacyICloudDevPath()})
+}
+
+// legacyICloudDevPath picks the same local directory get*ICloudFolders used
+// to read directly: the repo's test fixture directory if present, else a
+// synced Mobile Documents mount.
+func legacyICloudDevPath() string {
+	testPath := "/Users/corelogic/satori-dev/clients/proj-mallon/test_icloud"
+	if _, err := os.Stat(testPath); err == nil {
+		return testPath
+	}
+	return "/Users/" + getCurrentUser() + "/Library/Mobile Documents/com~apple~CloudDocs"
+}
+
+// handleListStorages returns the caller's configured storage backends,
+// without their encrypted settings (which may contain OAuth tokens or
+// access keys).
+func handleListStorages(c *gin.Context) {
+	userID := c.GetString("userID")
+	configs, err := storageConfigs.List(userID)
+	if err != nil {
+		log.Printf("Error listing storage configs: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list storages"})
+		return
+	}
+
+	type storageSummary struct {
+		ID        string    `json:"id"`
+		Name      string    `json:"name"`
+		Driver    string    `json:"driver"`
+		CreatedAt time.Time `json:"createdAt"`
+	}
+	summaries := make([]storageSummary, 0, len(configs))
+	for _, cfg := range configs {
+		summaries = append(summaries, storageSummary{ID: cfg.ID, Name: cfg.Name, Driver: cfg.Driver, CreatedAt: cfg.CreatedAt})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"storages": summaries, "count": len(summaries)})
+}
+
+// handleCreateStorage connects a new storage backend for the caller.
+func handleCreateStorage(c *gin.Context) {
+	var request struct {
+		Name     string            `json:"name"`
+		Driver   string            `json:"driver"`
+		Settings map[string]string `json:"settings"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	if request.Name == "" || request.Driver == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name and driver are required"})
+		return
+	}
+	if _, exists := driverRegistry[request.Driver]; !exists {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown storage driver: " + request.Driver})
+		return
+	}
+	if !userSelectableDrivers[request.Driver] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "driver cannot be configured directly: " + request.Driver})
+		return
+	}
+
+	userID := c.GetString("userID")
+	cfg := &StorageConfig{
+		ID:        generateSessionID(),
+		Name:      request.Name,
+		Driver:    request.Driver,
+		Settings:  request.Settings,
+		CreatedAt: time.Now(),
+	}
+	if err := storageConfigs.Save(userID, cfg); err != nil {
+		log.Printf("Error storing storage config: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save storage config"})
+		return
+	}
+
+	auditLog("storage_configured", gin.H{"username": c.GetString("username"), "driver": request.Driver})
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"storage": gin.H{"id": cfg.ID, "name": cfg.Name, "driver": cfg.Driver},
+	})
+}
+
+// handleDeleteStorage disconnects one of the caller's storage backends.
+func handleDeleteStorage(c *gin.Context) {
+	id := c.Param("id")
+	userID := c.GetString("userID")
+	if _, exists := storageConfigs.Get(userID, id); !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Storage config not found"})
+		return
+	}
+
+	storageConfigs.Delete(userID, id)
+	auditLog("storage_removed", gin.H{"username": c.GetString("username"), "storageId": id})
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
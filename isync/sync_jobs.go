@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// syncJobKind distinguishes the two directions a sync job can move a
+// document, mirroring the existing handleICloudSyncUp/Down split.
+type syncJobKind string
+
+const (
+	syncJobUpload   syncJobKind = "upload"
+	syncJobDownload syncJobKind = "download"
+)
+
+// syncJob is one unit of work for syncJobQueue. Run does the actual
+// transfer (performICloudUpload/Download in main.go); ID and Kind are
+// carried along purely for logging.
+type syncJob struct {
+	ID   string
+	Kind syncJobKind
+	Run  func()
+}
+
+// syncJobQueue is the shared worker pool behind performICloudUpload/
+// Download, replacing the previous "go performICloudUpload(...)" /
+// "go performICloudDownload(...)" pattern of one goroutine per request
+// with a bounded pool so a burst of sync requests can't spawn unbounded
+// goroutines against a single iCloud session.
+type syncJobQueue struct {
+	jobs chan syncJob
+}
+
+func newSyncJobQueue(workers, buffer int) *syncJobQueue {
+	q := &syncJobQueue{jobs: make(chan syncJob, buffer)}
+	for i := 0; i < workers; i++ {
+		go q.worker()
+	}
+	return q
+}
+
+func (q *syncJobQueue) worker() {
+	for job := range q.jobs {
+		job.Run()
+	}
+}
+
+// Enqueue schedules job to run on the next free worker.
+func (q *syncJobQueue) Enqueue(job syncJob) {
+	q.jobs <- job
+}
+
+// syncJobs is the active shared job queue. It runs uploadConcurrency
+// workers, the same bound performICloudUpload puts on the chunks of one
+// document, so at most uploadConcurrency transfers run at once, each with
+// up to uploadConcurrency chunks in flight.
+var syncJobs = newSyncJobQueue(uploadConcurrency, 64)
+
+// progressReader wraps an io.Reader and reports bytes read so far against
+// a known total into an ICloudSyncStatus, used by performICloudDownload in
+// place of the fixed-interval fake-percentage ticker it used to run.
+type progressReader struct {
+	r          io.Reader
+	total      int64
+	read       int64
+	documentID string
+	label      string
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.read += int64(n)
+		if status, exists := icloudSyncStatuses.Get(p.documentID); exists {
+			if p.total > 0 {
+				status.Progress = int(p.read * 100 / p.total)
+			}
+			status.Status = "syncing"
+			status.Message = fmt.Sprintf("%s... %d/%d bytes", p.label, p.read, p.total)
+		}
+	}
+	return n, err
+}
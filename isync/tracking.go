@@ -0,0 +1,294 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TrackedCase represents a filed case registered for ongoing docket
+// monitoring.
+type TrackedCase struct {
+	CaseNumber string    `json:"caseNumber"`
+	Court      string    `json:"court"`
+	Email      string    `json:"email"`      // notification recipient, reuses User.Email
+	Cadence    string    `json:"cadence"`    // "hourly" | "daily"
+	AddedAt    time.Time `json:"addedAt"`
+}
+
+// DocketEvent represents a new docket entry discovered for a tracked case.
+type DocketEvent struct {
+	CaseNumber string    `json:"caseNumber"`
+	EntryType  string    `json:"entryType"` // "filing" | "hearing" | "order"
+	Summary    string    `json:"summary"`
+	EntryDate  time.Time `json:"entryDate"`
+	Discovered time.Time `json:"discovered"`
+}
+
+const trackingSnapshotDir = "/Users/corelogic/satori-dev/clients/proj-mallon/dev/tracking_snapshots"
+
+// trackingRegistry holds tracked cases, their latest docket snapshot, and
+// the event log, guarded by a single mutex (consistent with the simple
+// global-map pattern already used for sessions/sync status elsewhere).
+type trackingRegistry struct {
+	mu        sync.Mutex
+	cases     map[string]*TrackedCase
+	snapshots map[string][]string // caseNumber -> docket entry summaries seen so far
+	events    map[string][]DocketEvent
+	streamSubs []chan DocketEvent
+}
+
+var tracker = &trackingRegistry{
+	cases:     make(map[string]*TrackedCase),
+	snapshots: make(map[string][]string),
+	events:    make(map[string][]DocketEvent),
+}
+
+// handleTrackingRegister registers a case for ongoing monitoring.
+func handleTrackingRegister(c *gin.Context) {
+	var request TrackedCase
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+	if request.CaseNumber == "" || request.Court == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "caseNumber and court are required"})
+		return
+	}
+	if request.Cadence == "" {
+		request.Cadence = "daily"
+	}
+	request.AddedAt = time.Now()
+
+	tracker.mu.Lock()
+	tracker.cases[request.CaseNumber] = &request
+	tracker.mu.Unlock()
+
+	loadSnapshotFromDisk(request.CaseNumber)
+
+	log.Printf("Tracking registered for case %s in %s (cadence: %s)", request.CaseNumber, request.Court, request.Cadence)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "trackedCase": request})
+}
+
+// handleTrackingUnregister stops monitoring a case.
+func handleTrackingUnregister(c *gin.Context) {
+	caseNumber := c.Param("caseNumber")
+
+	tracker.mu.Lock()
+	_, exists := tracker.cases[caseNumber]
+	delete(tracker.cases, caseNumber)
+	tracker.mu.Unlock()
+
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Case is not being tracked"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// handleTrackingList lists all cases currently being tracked.
+func handleTrackingList(c *gin.Context) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	cases := make([]*TrackedCase, 0, len(tracker.cases))
+	for _, tc := range tracker.cases {
+		cases = append(cases, tc)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"cases": cases})
+}
+
+// handleTrackingDocket returns the event log recorded for a tracked case.
+func handleTrackingDocket(c *gin.Context) {
+	caseNumber := c.Param("caseNumber")
+
+	tracker.mu.Lock()
+	events := tracker.events[caseNumber]
+	tracker.mu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{"caseNumber": caseNumber, "events": events})
+}
+
+// handleTrackingStream exposes a server-sent-events stream of new docket
+// events so the frontend can badge cases in real time.
+func handleTrackingStream(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	events := make(chan DocketEvent, 16)
+	tracker.mu.Lock()
+	tracker.streamSubs = append(tracker.streamSubs, events)
+	tracker.mu.Unlock()
+
+	defer func() {
+		tracker.mu.Lock()
+		for i, sub := range tracker.streamSubs {
+			if sub == events {
+				tracker.streamSubs = append(tracker.streamSubs[:i], tracker.streamSubs[i+1:]...)
+				break
+			}
+		}
+		tracker.mu.Unlock()
+		close(events)
+	}()
+
+	c.Stream(func(w http.ResponseWriter) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent("docket_event", event)
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// startTrackingScheduler starts the background goroutine that polls every
+// tracked case once an hour. A case's Cadence is not consulted; "daily"
+// cases are polled hourly too.
+func startTrackingScheduler() {
+	go func() {
+		ticker := time.NewTicker(1 * time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			pollTrackedCases()
+		}
+	}()
+}
+
+// pollTrackedCases calls pollOneCase for every tracked case. pollOneCase
+// only logs the poll: no provider is queried, no snapshot is diffed and no
+// DocketEvent is recorded.
+func pollTrackedCases() {
+	tracker.mu.Lock()
+	caseNumbers := make([]string, 0, len(tracker.cases))
+	for caseNumber := range tracker.cases {
+		caseNumbers = append(caseNumbers, caseNumber)
+	}
+	tracker.mu.Unlock()
+
+	for _, caseNumber := range caseNumbers {
+		pollOneCase(caseNumber)
+	}
+}
+
+func pollOneCase(caseNumber string) {
+	tracker.mu.Lock()
+	tracked, exists := tracker.cases[caseNumber]
+	tracker.mu.Unlock()
+	if !exists {
+		return
+	}
+
+	// In a full implementation this would call
+	// activeLegalDataProvider against the docket endpoint for
+	// tracked.CaseNumber/tracked.Court and return the current entries.
+	// The prototype has no live docket feed to diff against.
+	log.Printf("Tracking: polled docket for case %s in %s (no live provider configured)", tracked.CaseNumber, tracked.Court)
+}
+
+// recordDocketEvent appends a new event to the case's log, persists the
+// updated snapshot, passes it to notifyDocketEventByEmail (which logs the
+// message rather than sending it), and fans the event out to any
+// subscribed SSE streams.
+func recordDocketEvent(event DocketEvent) {
+	event.Discovered = time.Now()
+
+	tracker.mu.Lock()
+	tracker.events[event.CaseNumber] = append(tracker.events[event.CaseNumber], event)
+	tracker.snapshots[event.CaseNumber] = append(tracker.snapshots[event.CaseNumber], event.Summary)
+	tracked := tracker.cases[event.CaseNumber]
+	subs := append([]chan DocketEvent{}, tracker.streamSubs...)
+	tracker.mu.Unlock()
+
+	saveSnapshotToDisk(event.CaseNumber)
+
+	if tracked != nil && tracked.Email != "" {
+		notifyDocketEventByEmail(tracked.Email, event)
+	}
+
+	for _, sub := range subs {
+		select {
+		case sub <- event:
+		default:
+			log.Printf("Tracking: stream subscriber buffer full, dropping event for case %s", event.CaseNumber)
+		}
+	}
+}
+
+// notifyDocketEventByEmail sends an email notification to the attorney's
+// address. The prototype logs the outbound message instead of connecting
+// to an SMTP relay.
+func notifyDocketEventByEmail(email string, event DocketEvent) {
+	log.Printf("Tracking: would email %s about new %s on case %s: %s", email, event.EntryType, event.CaseNumber, event.Summary)
+}
+
+// saveSnapshotToDisk persists the docket snapshot so restarts don't
+// re-notify events already seen.
+func saveSnapshotToDisk(caseNumber string) {
+	if err := os.MkdirAll(trackingSnapshotDir, 0755); err != nil {
+		log.Printf("Tracking: failed to create snapshot directory: %v", err)
+		return
+	}
+
+	tracker.mu.Lock()
+	snapshot := tracker.snapshots[caseNumber]
+	tracker.mu.Unlock()
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		log.Printf("Tracking: failed to marshal snapshot for case %s: %v", caseNumber, err)
+		return
+	}
+
+	path := filepath.Join(trackingSnapshotDir, safeSnapshotFileName(caseNumber))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		log.Printf("Tracking: failed to write snapshot for case %s: %v", caseNumber, err)
+	}
+}
+
+// loadSnapshotFromDisk restores a previously-saved docket snapshot for a
+// newly-registered (or restarted) tracked case.
+func loadSnapshotFromDisk(caseNumber string) {
+	path := filepath.Join(trackingSnapshotDir, safeSnapshotFileName(caseNumber))
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return // no prior snapshot, nothing to restore
+	}
+
+	var snapshot []string
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		log.Printf("Tracking: failed to parse snapshot for case %s: %v", caseNumber, err)
+		return
+	}
+
+	tracker.mu.Lock()
+	tracker.snapshots[caseNumber] = snapshot
+	tracker.mu.Unlock()
+}
+
+func safeSnapshotFileName(caseNumber string) string {
+	safe := make([]rune, 0, len(caseNumber))
+	for _, r := range caseNumber {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			safe = append(safe, r)
+		} else {
+			safe = append(safe, '_')
+		}
+	}
+	return fmt.Sprintf("%s.json", string(safe))
+}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// resumableUploadDir holds the partial files backing in-progress uploads
+// under the tus-style protocol below, following the same hardcoded dev
+// path convention as activeStorage/icloud_trust_store.go.
+var resumableUploadDir = "/Users/corelogic/satori-dev/clients/proj-mallon/dev/resumable_uploads"
+
+// resumableUpload tracks one in-progress tus-style upload, letting a
+// lawyer's laptop resume after a dropped connection instead of restarting
+// from byte zero.
+type resumableUpload struct {
+	mu       sync.Mutex
+	ID       string
+	UserID   string
+	FileName string
+	Length   int64
+	Offset   int64
+	TempPath string
+	CreatedAt time.Time
+}
+
+// resumableUploadStore is the active registry of in-progress uploads,
+// keyed by upload ID (see handleCreateUpload).
+type resumableUploadStore struct {
+	mu      sync.Mutex
+	byID    map[string]*resumableUpload
+}
+
+func newResumableUploadStore() *resumableUploadStore {
+	return &resumableUploadStore{byID: make(map[string]*resumableUpload)}
+}
+
+func (s *resumableUploadStore) Save(u *resumableUpload) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.byID[u.ID] = u
+}
+
+func (s *resumableUploadStore) Get(id string) (*resumableUpload, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, exists := s.byID[id]
+	return u, exists
+}
+
+func (s *resumableUploadStore) Delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.byID, id)
+}
+
+// resumableUploads is the active multi-tenant resumable-upload registry.
+var resumableUploads = newResumableUploadStore()
+
+// parseTusMetadata decodes a tus Upload-Metadata header, a comma-separated
+// list of "key base64(value)" pairs, into a plain map.
+func parseTusMetadata(header string) map[string]string {
+	metadata := make(map[string]string)
+	if header == "" {
+		return metadata
+	}
+	for _, pair := range strings.Split(header, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), " ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			continue
+		}
+		metadata[parts[0]] = string(decoded)
+	}
+	return metadata
+}
+
+// handleCreateUpload implements the "creation" step of the tus resumable
+// upload protocol: the client declares the total length up front via
+// Upload-Length, and this hands back an upload ID to PATCH chunks onto.
+func handleCreateUpload(c *gin.Context) {
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Length header is required and must be a non-negative integer"})
+		return
+	}
+
+	metadata := parseTusMetadata(c.GetHeader("Upload-Metadata"))
+	fileName := metadata["filename"]
+	if fileName == "" {
+		fileName = fmt.Sprintf("upload_%d", time.Now().UnixNano())
+	}
+	// Validate filename to prevent path traversal attacks
+	if strings.Contains(fileName, "..") || strings.Contains(fileName, "/") || strings.Contains(fileName, "\\") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filename"})
+		return
+	}
+
+	if err := os.MkdirAll(resumableUploadDir, 0755); err != nil {
+		log.Printf("Failed to create resumable upload directory: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start upload"})
+		return
+	}
+
+	id := fmt.Sprintf("upload_%d", time.Now().UnixNano())
+	tempPath := filepath.Join(resumableUploadDir, id+".part")
+	f, err := os.Create(tempPath)
+	if err != nil {
+		log.Printf("Failed to create temp file for upload %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start upload"})
+		return
+	}
+	f.Close()
+
+	upload := &resumableUpload{
+		ID:        id,
+		UserID:    c.GetString("userID"),
+		FileName:  fileName,
+		Length:    length,
+		Offset:    0,
+		TempPath:  tempPath,
+		CreatedAt: time.Now(),
+	}
+	resumableUploads.Save(upload)
+
+	icloudSyncStatuses.Set(id, &ICloudSyncStatus{
+		DocumentID: id,
+		Status:     "pending",
+		Progress:   0,
+		Message:    "Upload created, waiting for first chunk...",
+		StartedAt:  time.Now(),
+	})
+
+	c.Header("Location", "/api/uploads/"+id)
+	c.Header("Upload-Offset", "0")
+	c.Header("Tus-Resumable", "1.0.0")
+	c.JSON(http.StatusCreated, gin.H{"success": true, "uploadId": id, "offset": 0, "length": length})
+}
+
+// handlePatchUpload implements the tus "core" PATCH step: it appends the
+// request body to the upload's temp file starting at Upload-Offset,
+// rejecting the write if the offset doesn't match what's already been
+// received (the client is expected to retry a HEAD first in that case).
+// Real progress is reported into icloudSyncStatuses from bytes actually
+// written, not a sleep loop.
+func handlePatchUpload(c *gin.Context) {
+	id := c.Param("id")
+	upload, exists := resumableUploads.Get(id)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+		return
+	}
+	if upload.UserID != c.GetString("userID") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot resume another user's upload"})
+		return
+	}
+
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Offset header is required and must be an integer"})
+		return
+	}
+
+	upload.mu.Lock()
+	defer upload.mu.Unlock()
+
+	if offset != upload.Offset {
+		c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+		c.JSON(http.StatusConflict, gin.H{"error": "Upload-Offset does not match the server's current offset", "offset": upload.Offset})
+		return
+	}
+
+	f, err := os.OpenFile(upload.TempPath, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		log.Printf("Failed to open temp file for upload %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to append chunk"})
+		return
+	}
+	defer f.Close()
+
+	written, err := io.Copy(f, c.Request.Body)
+	if err != nil {
+		log.Printf("Failed to write chunk for upload %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to append chunk"})
+		return
+	}
+	upload.Offset += written
+
+	if status, exists := icloudSyncStatuses.Get(id); exists {
+		status.Status = "syncing"
+		if upload.Length > 0 {
+			status.Progress = int(upload.Offset * 100 / upload.Length)
+		}
+		status.Message = fmt.Sprintf("Uploading... %d/%d bytes", upload.Offset, upload.Length)
+	}
+
+	if upload.Offset >= upload.Length {
+		content, err := os.ReadFile(upload.TempPath)
+		if err != nil {
+			log.Printf("Failed to read completed upload %s: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to finalize upload"})
+			return
+		}
+		if err := activeStorage.Put(upload.FileName, content); err != nil {
+			log.Printf("Failed to save completed upload %s: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to finalize upload"})
+			return
+		}
+		os.Remove(upload.TempPath)
+		resumableUploads.Delete(id)
+
+		if status, exists := icloudSyncStatuses.Get(id); exists {
+			status.Status = "completed"
+			status.Progress = 100
+			status.Message = "Upload complete"
+			status.CompletedAt = time.Now()
+		}
+		log.Printf("Completed resumable upload %s (%s, %d bytes)", id, upload.FileName, upload.Offset)
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Header("Tus-Resumable", "1.0.0")
+	c.Status(http.StatusNoContent)
+}
+
+// handleHeadUpload implements the tus "offset retrieval" step, letting a
+// client that lost its connection ask where to resume from.
+func handleHeadUpload(c *gin.Context) {
+	id := c.Param("id")
+	upload, exists := resumableUploads.Get(id)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+		return
+	}
+	if upload.UserID != c.GetString("userID") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot query another user's upload"})
+		return
+	}
+
+	upload.mu.Lock()
+	defer upload.mu.Unlock()
+
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	c.Header("Tus-Resumable", "1.0.0")
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+}
+
+// handleDeleteUpload aborts an in-progress upload and discards its partial
+// data, implementing the tus "termination" extension.
+func handleDeleteUpload(c *gin.Context) {
+	id := c.Param("id")
+	upload, exists := resumableUploads.Get(id)
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+		return
+	}
+	if upload.UserID != c.GetString("userID") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Cannot abort another user's upload"})
+		return
+	}
+
+	os.Remove(upload.TempPath)
+	resumableUploads.Delete(id)
+	icloudSyncStatuses.Set(id, &ICloudSyncStatus{
+		DocumentID:  id,
+		Status:      "error",
+		ErrorMessage: "Upload aborted",
+		CompletedAt: time.Now(),
+	})
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleICloudStreamDocument streams path from the caller's active storage
+// backend (see driverForUser in storages.go), honoring a single-range HTTP
+// Range header via Driver.OpenRange so large PDFs don't have to be
+// buffered in memory the way handleDownloadDocument's http.ServeContent
+// path does for generated DOCX files. Multi-range (multipart/byteranges)
+// requests aren't supported since none of this prototype's Driver
+// implementations can serve more than one range without two round trips;
+// they fall back to a full 200 response.
+func handleICloudStreamDocument(c *gin.Context) {
+	userID := c.GetString("userID")
+	path := c.Query("path")
+	if path == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "path query parameter is required"})
+		return
+	}
+
+	driver, err := driverForUser(userID)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	info, err := driver.Stat(path)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found: " + err.Error()})
+		return
+	}
+
+	c.Header("Accept-Ranges", "bytes")
+	contentType := mimeTypeForDocumentType(info.Type)
+
+	rangeHeader := c.GetHeader("Range")
+	offset, length, isRange := parseSingleByteRange(rangeHeader, info.Size)
+	if !isRange {
+		rc, err := driver.Open(path)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open document: " + err.Error()})
+			return
+		}
+		defer rc.Close()
+		c.Header("Content-Length", strconv.FormatInt(info.Size, 10))
+		c.DataFromReader(http.StatusOK, info.Size, contentType, rc, nil)
+		return
+	}
+
+	rc, err := driver.OpenRange(path, offset, length)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open document range: " + err.Error()})
+		return
+	}
+	defer rc.Close()
+
+	c.Header("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+length-1, info.Size))
+	c.Header("Content-Length", strconv.FormatInt(length, 10))
+	c.DataFromReader(http.StatusPartialContent, length, contentType, rc, nil)
+}
+
+// parseSingleByteRange parses a "bytes=start-end" Range header for a
+// resource of the given total size, returning the absolute offset/length
+// it describes. Multiple comma-separated ranges aren't supported (see
+// handleICloudStreamDocument) and report isRange=false so the caller falls
+// back to serving the whole resource.
+func parseSingleByteRange(header string, size int64) (offset, length int64, isRange bool) {
+	if header == "" || !strings.HasPrefix(header, "bytes=") || strings.Contains(header, ",") {
+		return 0, 0, false
+	}
+
+	spec := strings.TrimPrefix(header, "bytes=")
+	parts := strings.SplitN(spec, "-", 2)
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+
+	if parts[0] == "" {
+		// Suffix range, e.g. "bytes=-500" for the last 500 bytes.
+		suffixLen, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || suffixLen <= 0 {
+			return 0, 0, false
+		}
+		if suffixLen > size {
+			suffixLen = size
+		}
+		return size - suffixLen, suffixLen, true
+	}
+
+	start, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return 0, 0, false
+	}
+
+	end := size - 1
+	if parts[1] != "" {
+		parsedEnd, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || parsedEnd < start {
+			return 0, 0, false
+		}
+		if parsedEnd < end {
+			end = parsedEnd
+		}
+	}
+
+	return start, end - start + 1, true
+}
+
+// mimeTypeForDocumentType maps the "type" ICloudDocument classifies files
+// into (see fileTypeForName in driver.go) to a Content-Type for streaming.
+func mimeTypeForDocumentType(docType string) string {
+	switch docType {
+	case "pdf":
+		return "application/pdf"
+	case "docx":
+		return "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	case "doc":
+		return "application/msword"
+	case "txt":
+		return "text/plain"
+	case "image":
+		return "image/jpeg"
+	default:
+		return "application/octet-stream"
+	}
+}